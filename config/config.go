@@ -5,27 +5,150 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 type HealthCheck struct {
-	Type     string `yaml:"type" json:"type"`         // "tcp" or "http"
-	Target   string `yaml:"target" json:"target"`     // "localhost:8080" or "http://localhost..."
-	Interval int    `yaml:"interval" json:"interval"` // ms
-	Timeout  int    `yaml:"timeout" json:"timeout"`   // ms
+	Type                 string `yaml:"type" json:"type"`                                                         // "tcp", "http", "grpc", "exec" or "log"
+	Target               string `yaml:"target" json:"target"`                                                     // "localhost:8080" or "http://localhost..."
+	Command              string `yaml:"command,omitempty" json:"command,omitempty"`                               // exec: probe run like the task's command
+	Pattern              string `yaml:"pattern,omitempty" json:"pattern,omitempty"`                               // log: regexp marking the task healthy
+	UnhealthyPattern     string `yaml:"unhealthy_pattern,omitempty" json:"unhealthy_pattern,omitempty"`           // log: regexp marking it unhealthy again
+	Interval             int    `yaml:"interval" json:"interval"`                                                 // ms
+	Timeout              int    `yaml:"timeout" json:"timeout"`                                                   // ms
+	StartPeriod          int    `yaml:"start_period,omitempty" json:"start_period,omitempty"`                     // ms during which failures are ignored
+	FailureThreshold     int    `yaml:"failure_threshold,omitempty" json:"failure_threshold,omitempty"`           // Consecutive failures before Unhealthy (default 3)
+	SuccessThreshold     int    `yaml:"success_threshold,omitempty" json:"success_threshold,omitempty"`           // Consecutive successes before Healthy (default 1)
+	OnUnhealthy          string `yaml:"on_unhealthy,omitempty" json:"on_unhealthy,omitempty"`                     // "restart" to restart tasks that stay unhealthy
+	UnhealthyTimeout     int    `yaml:"unhealthy_timeout,omitempty" json:"unhealthy_timeout,omitempty"`           // ms unhealthy before on_unhealthy applies (default 30000)
+	MaxUnhealthyRestarts int    `yaml:"max_unhealthy_restarts,omitempty" json:"max_unhealthy_restarts,omitempty"` // Restarts without becoming healthy, 0 = unlimited
+
+	// HTTP options
+	Method             string            `yaml:"method,omitempty" json:"method,omitempty"`                             // Default GET
+	Headers            map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`                           // Request headers
+	ExpectedStatus     StatusList        `yaml:"expected_status,omitempty" json:"expected_status,omitempty"`           // Default any 2xx or 3xx
+	BodyContains       string            `yaml:"body_contains,omitempty" json:"body_contains,omitempty"`               // Substring the body must contain
+	BodyRegex          string            `yaml:"body_regex,omitempty" json:"body_regex,omitempty"`                     // Regexp the body must match
+	JSON               map[string]string `yaml:"json,omitempty" json:"json,omitempty"`                                 // Dotted JSON path -> expected value
+	InsecureSkipVerify bool              `yaml:"insecure_skip_verify,omitempty" json:"insecure_skip_verify,omitempty"` // Also applies to grpc
+
+	// gRPC options
+	Service string `yaml:"service,omitempty" json:"service,omitempty"` // Service name sent in the Check request, "" for the server
+	TLS     bool   `yaml:"tls,omitempty" json:"tls,omitempty"`         // Connect with TLS instead of plaintext
+	CAFile  string `yaml:"ca_file,omitempty" json:"ca_file,omitempty"` // PEM roots to verify the server with
 }
 
+// Health check types
+const (
+	HealthTCP  = "tcp"
+	HealthHTTP = "http"
+	HealthGRPC = "grpc"
+	HealthExec = "exec"
+	HealthLog  = "log"
+)
+
+// Actions for tasks that stay unhealthy
+const (
+	OnUnhealthyNone    = "none"
+	OnUnhealthyRestart = "restart"
+)
+
 type Task struct {
 	Name        string       `yaml:"name" json:"name"`
+	Type        string       `yaml:"type,omitempty" json:"type,omitempty"`           // "service" (default) or "oneshot"
+	Autostart   *bool        `yaml:"autostart,omitempty" json:"autostart,omitempty"` // Start with DevDeck (default true)
 	Command     string       `yaml:"command" json:"command"`
+	Args        []string     `yaml:"args,omitempty" json:"args,omitempty"`   // Exact argv, no parsing
+	Shell       string       `yaml:"shell,omitempty" json:"shell,omitempty"` // Interpreter for Command, "none" to disable
 	Directory   string       `yaml:"directory,omitempty" json:"directory,omitempty"`
 	Env         []string     `yaml:"env,omitempty" json:"env,omitempty"`
 	EnvFile     string       `yaml:"env_file,omitempty" json:"env_file,omitempty"`
 	HealthCheck *HealthCheck `yaml:"health_check,omitempty" json:"health_check,omitempty"`
-	DependsOn   []string     `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
+	DependsOn   Dependencies `yaml:"depends_on,omitempty" json:"depends_on,omitempty"` // Names, or names -> condition
 	Groups      []string     `yaml:"groups,omitempty" json:"groups,omitempty"`
+	TTY         bool         `yaml:"tty,omitempty" json:"tty,omitempty"`                     // Run inside a pseudo-terminal
+	MaxLogLines int          `yaml:"max_log_lines,omitempty" json:"max_log_lines,omitempty"` // Log lines kept in memory
+	LogOverflow string       `yaml:"log_overflow,omitempty" json:"log_overflow,omitempty"`   // "drop-oldest" (default), "drop-newest", "spill"
+	StopSignal  string       `yaml:"stop_signal,omitempty" json:"stop_signal,omitempty"`     // "SIGTERM" (default), "SIGINT", ...
+	StopTimeout int          `yaml:"stop_timeout,omitempty" json:"stop_timeout,omitempty"`   // ms before SIGKILL (default 5000)
+
+	Restart           string `yaml:"restart,omitempty" json:"restart,omitempty"`                         // "no", "on-failure", "always", "unless-stopped"
+	MaxRestarts       int    `yaml:"max_restarts,omitempty" json:"max_restarts,omitempty"`               // 0 = unlimited
+	RestartBackoff    int    `yaml:"restart_backoff,omitempty" json:"restart_backoff,omitempty"`         // ms, initial delay (default 1000)
+	RestartMaxBackoff int    `yaml:"restart_max_backoff,omitempty" json:"restart_max_backoff,omitempty"` // ms, delay cap (default 30000)
+	RestartReset      int    `yaml:"restart_reset,omitempty" json:"restart_reset,omitempty"`             // ms of uptime that clears the counter (default 60000)
+	RestartDependents bool   `yaml:"restart_dependents,omitempty" json:"restart_dependents,omitempty"`   // 'r' also restarts dependent tasks
+}
+
+// ShouldAutostart reports whether the task starts when DevDeck starts.
+func (t Task) ShouldAutostart() bool {
+	return t.Autostart == nil || *t.Autostart
+}
+
+// Task types
+const (
+	TaskService = "service" // Long-running, expected to stay up
+	TaskOneshot = "oneshot" // Expected to finish, e.g. a migration
+)
+
+// Log overflow policies
+const (
+	OverflowDropOldest = "drop-oldest"
+	OverflowDropNewest = "drop-newest"
+	OverflowSpill      = "spill"
+)
+
+// StopSignals lists the signals stop_signal accepts. Names are matched
+// case-insensitively, with or without the SIG prefix.
+var StopSignals = []string{"SIGTERM", "SIGINT", "SIGQUIT", "SIGHUP", "SIGKILL", "SIGUSR1", "SIGUSR2"}
+
+// StopSignalName returns the StopSignals entry a stop_signal value such as
+// "term" refers to, SIGTERM if it's empty, and whether there is one.
+func StopSignalName(value string) (string, bool) {
+	if value == "" {
+		return "SIGTERM", true
+	}
+	name := strings.ToUpper(strings.TrimSpace(value))
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	for _, s := range StopSignals {
+		if s == name {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Restart policies
+const (
+	RestartNo            = "no"
+	RestartOnFailure     = "on-failure"
+	RestartAlways        = "always"
+	RestartUnlessStopped = "unless-stopped"
+)
+
+// probeOnlyField returns the first set option of hc that only applies to
+// checks that probe the task, or "".
+func probeOnlyField(hc *HealthCheck) string {
+	switch {
+	case hc.StartPeriod != 0:
+		return "start_period"
+	case hc.FailureThreshold != 0:
+		return "failure_threshold"
+	case hc.SuccessThreshold != 0:
+		return "success_threshold"
+	case hc.OnUnhealthy != "" && hc.OnUnhealthy != OnUnhealthyNone:
+		return "on_unhealthy"
+	case hc.UnhealthyTimeout != 0:
+		return "unhealthy_timeout"
+	case hc.MaxUnhealthyRestarts != 0:
+		return "max_unhealthy_restarts"
+	}
+	return ""
 }
 
 type Theme struct {
@@ -33,13 +156,19 @@ type Theme struct {
 	Secondary string `yaml:"secondary" json:"secondary"`
 	Border    string `yaml:"border" json:"border"`
 	Text      string `yaml:"text" json:"text"`
+	Stderr    string `yaml:"stderr" json:"stderr"` // Color of stderr lines
 }
 
 type Config struct {
-	Tasks []Task `yaml:"tasks" json:"tasks"`
-	Theme *Theme `yaml:"theme,omitempty" json:"theme,omitempty"`
+	Tasks       []Task `yaml:"tasks" json:"tasks"`
+	Shell       string `yaml:"shell,omitempty" json:"shell,omitempty"`                 // Default interpreter for all tasks
+	MaxLogLines int    `yaml:"max_log_lines,omitempty" json:"max_log_lines,omitempty"` // Default log lines kept per task
+	Theme       *Theme `yaml:"theme,omitempty" json:"theme,omitempty"`
 }
 
+// ShellNone disables shell execution for a task even if a global shell is set.
+const ShellNone = "none"
+
 func LoadConfig(path string) (*Config, error) {
 	file, err := os.ReadFile(path)
 	if err != nil {
@@ -75,6 +204,95 @@ func LoadConfig(path string) (*Config, error) {
 	for i := range config.Tasks {
 		task := &config.Tasks[i]
 
+		switch task.Restart {
+		case "", RestartNo, RestartOnFailure, RestartAlways, RestartUnlessStopped:
+		default:
+			return nil, fmt.Errorf("task %q: invalid restart policy %q", task.Name, task.Restart)
+		}
+
+		switch task.Type {
+		case "", TaskService:
+		case TaskOneshot:
+			if task.HealthCheck != nil {
+				return nil, fmt.Errorf("task %q: oneshot tasks can't have a health_check", task.Name)
+			}
+			if task.Restart == RestartAlways || task.Restart == RestartUnlessStopped {
+				return nil, fmt.Errorf("task %q: oneshot tasks can only use restart %q or %q", task.Name, RestartNo, RestartOnFailure)
+			}
+		default:
+			return nil, fmt.Errorf("task %q: invalid type %q", task.Name, task.Type)
+		}
+
+		switch task.LogOverflow {
+		case "", OverflowDropOldest, OverflowDropNewest, OverflowSpill:
+		default:
+			return nil, fmt.Errorf("task %q: invalid log_overflow policy %q", task.Name, task.LogOverflow)
+		}
+
+		if _, ok := StopSignalName(task.StopSignal); !ok {
+			return nil, fmt.Errorf("task %q: invalid stop_signal %q", task.Name, task.StopSignal)
+		}
+
+		for _, dep := range task.DependsOn {
+			switch dep.Condition {
+			case "", ConditionStarted, ConditionHealthy, ConditionCompleted:
+			default:
+				return nil, fmt.Errorf("task %q: invalid condition %q for dependency %q", task.Name, dep.Condition, dep.Name)
+			}
+			if dep.Condition == ConditionHealthy {
+				if other := config.task(dep.Name); other != nil && other.HealthCheck == nil {
+					return nil, fmt.Errorf("task %q: dependency %q has no health_check, it can't become %s", task.Name, dep.Name, ConditionHealthy)
+				}
+			}
+		}
+
+		if hc := task.HealthCheck; hc != nil {
+			switch hc.OnUnhealthy {
+			case "", OnUnhealthyNone, OnUnhealthyRestart:
+			default:
+				return nil, fmt.Errorf("task %q: invalid on_unhealthy action %q", task.Name, hc.OnUnhealthy)
+			}
+
+			switch hc.Type {
+			case HealthTCP:
+			case HealthGRPC:
+				if hc.CAFile != "" && !filepath.IsAbs(hc.CAFile) {
+					hc.CAFile = filepath.Join(configDir, hc.CAFile)
+				}
+			case HealthHTTP:
+				if _, err := regexp.Compile(hc.BodyRegex); err != nil {
+					return nil, fmt.Errorf("task %q: invalid health check body_regex: %w", task.Name, err)
+				}
+			case HealthExec:
+				if strings.TrimSpace(hc.Command) == "" {
+					return nil, fmt.Errorf("task %q: exec health check needs a command", task.Name)
+				}
+			case HealthLog:
+				if hc.Pattern == "" {
+					return nil, fmt.Errorf("task %q: log health check needs a pattern", task.Name)
+				}
+				for _, pattern := range []string{hc.Pattern, hc.UnhealthyPattern} {
+					if _, err := regexp.Compile(pattern); err != nil {
+						return nil, fmt.Errorf("task %q: invalid health check pattern: %w", task.Name, err)
+					}
+				}
+				// Log checks react to each matching line, there are no probes to count
+				if field := probeOnlyField(hc); field != "" {
+					return nil, fmt.Errorf("task %q: log health checks can't have %s", task.Name, field)
+				}
+			default:
+				return nil, fmt.Errorf("task %q: invalid health check type %q", task.Name, hc.Type)
+			}
+		}
+
+		// Inherit global settings
+		if task.Shell == "" {
+			task.Shell = config.Shell
+		}
+		if task.MaxLogLines == 0 {
+			task.MaxLogLines = config.MaxLogLines
+		}
+
 		// Resolve Directory
 		if task.Directory != "" && !filepath.IsAbs(task.Directory) {
 			task.Directory = filepath.Join(configDir, task.Directory)
@@ -98,9 +316,23 @@ func LoadConfig(path string) (*Config, error) {
 		}
 	}
 
+	if err := config.validateDependencies(); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
+// task returns the task called name, or nil.
+func (c *Config) task(name string) *Task {
+	for i := range c.Tasks {
+		if c.Tasks[i].Name == name {
+			return &c.Tasks[i]
+		}
+	}
+	return nil
+}
+
 func parseEnvFile(path string) ([]string, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
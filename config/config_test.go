@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadYAML writes a config file with the given content and loads it.
+func loadYAML(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "devdeck.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadConfig(path)
+}
+
+func TestLoadConfigStopSignal(t *testing.T) {
+	tests := []struct {
+		signal string
+		err    string
+	}{
+		{"", ""},
+		{"SIGINT", ""},
+		{"term", ""},
+		{" Sighup ", ""},
+		{"SIGSTOP", `task "app": invalid stop_signal "SIGSTOP"`},
+		{"15", `task "app": invalid stop_signal "15"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.signal, func(t *testing.T) {
+			_, err := loadYAML(t, "tasks:\n  - name: app\n    command: app\n    stop_signal: \""+tt.signal+"\"\n")
+			if tt.err == "" && err != nil {
+				t.Errorf("LoadConfig: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("LoadConfig error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigLogCheckOptions(t *testing.T) {
+	tests := []struct {
+		option string
+		err    string
+	}{
+		{"", ""},
+		{"on_unhealthy: none", ""},
+		{"on_unhealthy: restart", `task "app": log health checks can't have on_unhealthy`},
+		{"start_period: 5000", `task "app": log health checks can't have start_period`},
+		{"failure_threshold: 3", `task "app": log health checks can't have failure_threshold`},
+		{"success_threshold: 2", `task "app": log health checks can't have success_threshold`},
+		{"unhealthy_timeout: 1000", `task "app": log health checks can't have unhealthy_timeout`},
+		{"max_unhealthy_restarts: 2", `task "app": log health checks can't have max_unhealthy_restarts`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.option, func(t *testing.T) {
+			_, err := loadYAML(t, "tasks:\n  - name: app\n    command: app\n    health_check:\n      type: log\n      pattern: ready\n      "+tt.option+"\n")
+			if tt.err == "" && err != nil {
+				t.Errorf("LoadConfig: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("LoadConfig error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
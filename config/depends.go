@@ -0,0 +1,115 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dependency conditions
+const (
+	ConditionStarted   = "service_started"                // The task has been launched
+	ConditionHealthy   = "service_healthy"                // The task passes its health check
+	ConditionCompleted = "service_completed_successfully" // The task exited with status 0
+)
+
+// Dependency is a task that must reach Condition before the dependent starts.
+// An empty Condition waits for the task to be healthy if it has a health
+// check and running otherwise.
+type Dependency struct {
+	Name        string
+	Condition   string
+	WaitTimeout int // ms to wait before giving up, 0 = forever
+}
+
+func (d Dependency) String() string {
+	var opts []string
+	if d.Condition != "" {
+		opts = append(opts, d.Condition)
+	}
+	if d.WaitTimeout > 0 {
+		opts = append(opts, fmt.Sprintf("timeout %dms", d.WaitTimeout))
+	}
+	if len(opts) == 0 {
+		return d.Name
+	}
+	return fmt.Sprintf("%s (%s)", d.Name, strings.Join(opts, ", "))
+}
+
+// Dependencies is the depends_on list of a task. In the config it is either
+// a list of task names or a map of task names to their condition:
+//
+//	depends_on:
+//	  db:
+//	    condition: service_healthy
+type Dependencies []Dependency
+
+// Names returns the names of the dependencies.
+func (d Dependencies) Names() []string {
+	names := make([]string, len(d))
+	for i, dep := range d {
+		names[i] = dep.Name
+	}
+	return names
+}
+
+// dependencyOptions is the value of a task in the map form of depends_on.
+type dependencyOptions struct {
+	Condition   string `yaml:"condition" json:"condition"`
+	WaitTimeout int    `yaml:"wait_timeout" json:"wait_timeout"`
+}
+
+func (d *Dependencies) UnmarshalYAML(value *yaml.Node) error {
+	var deps Dependencies
+	switch value.Kind {
+	case yaml.SequenceNode:
+		var names []string
+		if err := value.Decode(&names); err != nil {
+			return fmt.Errorf("line %d: depends_on must be a list of task names or a map", value.Line)
+		}
+		for _, name := range names {
+			deps = append(deps, Dependency{Name: name})
+		}
+	case yaml.MappingNode:
+		// Decode pair by pair to keep the order of the file
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			var opts dependencyOptions
+			if err := value.Content[i+1].Decode(&opts); err != nil {
+				return fmt.Errorf("line %d: %w", value.Content[i+1].Line, err)
+			}
+			deps = append(deps, Dependency{Name: value.Content[i].Value, Condition: opts.Condition, WaitTimeout: opts.WaitTimeout})
+		}
+	default:
+		return fmt.Errorf("line %d: depends_on must be a list of task names or a map", value.Line)
+	}
+	*d = deps
+	return nil
+}
+
+func (d *Dependencies) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err == nil {
+		deps := make(Dependencies, len(names))
+		for i, name := range names {
+			deps[i] = Dependency{Name: name}
+		}
+		*d = deps
+		return nil
+	}
+
+	var m map[string]dependencyOptions
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("depends_on must be a list of task names or a map")
+	}
+	// JSON objects are unordered, sort for a stable start order
+	deps := make(Dependencies, 0, len(m))
+	for name, opts := range m {
+		deps = append(deps, Dependency{Name: name, Condition: opts.Condition, WaitTimeout: opts.WaitTimeout})
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	*d = deps
+	return nil
+}
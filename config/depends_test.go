@@ -0,0 +1,84 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDependenciesUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		json string
+		want Dependencies
+		err  bool
+	}{
+		{
+			name: "list",
+			yaml: `[db, cache]`,
+			json: `["db", "cache"]`,
+			want: Dependencies{{Name: "db"}, {Name: "cache"}},
+		},
+		{
+			name: "empty list",
+			yaml: `[]`,
+			json: `[]`,
+			want: nil,
+		},
+		{
+			name: "map",
+			yaml: "db:\n  condition: service_healthy\n  wait_timeout: 5000\nmigrate:\n  condition: service_completed_successfully\n",
+			json: `{"migrate": {"condition": "service_completed_successfully"}, "db": {"condition": "service_healthy", "wait_timeout": 5000}}`,
+			want: Dependencies{
+				{Name: "db", Condition: ConditionHealthy, WaitTimeout: 5000},
+				{Name: "migrate", Condition: ConditionCompleted},
+			},
+		},
+		{
+			name: "map without options",
+			yaml: "db: {}\n",
+			json: `{"db": {}}`,
+			want: Dependencies{{Name: "db"}},
+		},
+		{
+			name: "name",
+			yaml: `db`,
+			json: `"db"`,
+			err:  true,
+		},
+		{
+			name: "list of maps",
+			yaml: `[{db: service_started}]`,
+			json: `[{"db": "service_started"}]`,
+			err:  true,
+		},
+		{
+			name: "bad options",
+			yaml: "db:\n  wait_timeout: soon\n",
+			json: `{"db": {"wait_timeout": "soon"}}`,
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromYAML Dependencies
+			err := yaml.Unmarshal([]byte(tt.yaml), &fromYAML)
+			if (err != nil) != tt.err {
+				t.Errorf("YAML error = %v, want error %v", err, tt.err)
+			} else if !tt.err && !reflect.DeepEqual(fromYAML, tt.want) {
+				t.Errorf("YAML = %v, want %v", fromYAML, tt.want)
+			}
+
+			var fromJSON Dependencies
+			err = json.Unmarshal([]byte(tt.json), &fromJSON)
+			if (err != nil) != tt.err {
+				t.Errorf("JSON error = %v, want error %v", err, tt.err)
+			} else if !tt.err && len(tt.want) > 0 && !reflect.DeepEqual(fromJSON, tt.want) {
+				t.Errorf("JSON = %v, want %v", fromJSON, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateDependencies rejects duplicate task names, dependencies on tasks
+// that don't exist and dependency cycles.
+func (c *Config) validateDependencies() error {
+	names := make(map[string]bool, len(c.Tasks))
+	for _, task := range c.Tasks {
+		if names[task.Name] {
+			return fmt.Errorf("duplicate task name %q", task.Name)
+		}
+		names[task.Name] = true
+	}
+
+	for _, task := range c.Tasks {
+		for _, dep := range task.DependsOn {
+			if names[dep.Name] {
+				continue
+			}
+			if suggestion := c.suggestTask(dep.Name); suggestion != "" {
+				return fmt.Errorf("task %q: unknown dependency %q (did you mean %q?)", task.Name, dep.Name, suggestion)
+			}
+			return fmt.Errorf("task %q: unknown dependency %q", task.Name, dep.Name)
+		}
+	}
+
+	_, err := c.sortTasks()
+	return err
+}
+
+// StartOrder returns the task names so that every task comes after its
+// dependencies. Tasks keep their order in the file where possible.
+func (c *Config) StartOrder() []string {
+	// LoadConfig has rejected cycles already
+	order, _ := c.sortTasks()
+	return order
+}
+
+// sortTasks orders the tasks by a depth-first walk of the dependency graph
+// and reports the first cycle it finds, e.g. "a -> b -> a".
+func (c *Config) sortTasks() ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	marks := make(map[string]int, len(c.Tasks))
+	order := make([]string, 0, len(c.Tasks))
+	var path []string
+
+	var visit func(task *Task) error
+	visit = func(task *Task) error {
+		switch marks[task.Name] {
+		case done:
+			return nil
+		case visiting:
+			// The cycle is the part of the path from the first visit on
+			start := 0
+			for path[start] != task.Name {
+				start++
+			}
+			cycle := append(path[start:len(path):len(path)], task.Name)
+			return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		marks[task.Name] = visiting
+		path = append(path, task.Name)
+		for _, dep := range task.DependsOn {
+			if next := c.task(dep.Name); next != nil {
+				if err := visit(next); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		marks[task.Name] = done
+		order = append(order, task.Name)
+		return nil
+	}
+
+	for i := range c.Tasks {
+		if err := visit(&c.Tasks[i]); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// suggestTask returns the task name closest to a misspelled name, or "" if
+// none is close enough.
+func (c *Config) suggestTask(name string) string {
+	maxDist := len(name)/3 + 1
+	best, bestDist := "", maxDist+1
+	for _, task := range c.Tasks {
+		if strings.EqualFold(task.Name, name) {
+			return task.Name
+		}
+		if d := editDistance(strings.ToLower(task.Name), strings.ToLower(name)); d < bestDist {
+			best, bestDist = task.Name, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// task returns a task named name depending on the tasks in on.
+func task(name string, on ...string) Task {
+	t := Task{Name: name, Command: name}
+	for _, dep := range on {
+		t.DependsOn = append(t.DependsOn, Dependency{Name: dep})
+	}
+	return t
+}
+
+func TestSortTasks(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []Task
+		order string
+		err   string
+	}{
+		{"no dependencies", []Task{task("a"), task("b")}, "a b", ""},
+		{"dependency first", []Task{task("api", "db"), task("db")}, "db api", ""},
+		{"chain", []Task{task("web", "api"), task("api", "db"), task("db")}, "db api web", ""},
+		{"shared dependency", []Task{task("api", "db"), task("worker", "db"), task("db")}, "db api worker", ""},
+		{"self", []Task{task("a", "a")}, "", "dependency cycle: a -> a"},
+		{"pair", []Task{task("a", "b"), task("b", "a")}, "", "dependency cycle: a -> b -> a"},
+		{"cycle behind a task", []Task{task("web", "api"), task("api", "db"), task("db", "cache"), task("cache", "api")},
+			"", "dependency cycle: api -> db -> cache -> api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{Tasks: tt.tasks}
+			order, err := c.sortTasks()
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("sortTasks error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sortTasks: %v", err)
+			}
+			if got := strings.Join(order, " "); got != tt.order {
+				t.Errorf("order = %q, want %q", got, tt.order)
+			}
+		})
+	}
+}
+
+func TestSuggestTask(t *testing.T) {
+	c := &Config{Tasks: []Task{task("postgres"), task("api"), task("frontend")}}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"postgres", "postgres"},
+		{"Postgres", "postgres"},
+		{"postgre", "postgres"},
+		{"postgers", "postgres"},
+		{"apy", "api"},
+		{"frontned", "frontend"},
+		{"redis", ""},
+		{"db", ""},
+	}
+	for _, tt := range tests {
+		if got := c.suggestTask(tt.name); got != tt.want {
+			t.Errorf("suggestTask(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateDependencies(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []Task
+		err   string
+	}{
+		{"valid", []Task{task("api", "db"), task("db")}, ""},
+		{"duplicate", []Task{task("db"), task("db")}, `duplicate task name "db"`},
+		{"did you mean", []Task{task("api", "postgre"), task("postgres")}, `task "api": unknown dependency "postgre" (did you mean "postgres"?)`},
+		{"unknown", []Task{task("api", "redis"), task("postgres")}, `task "api": unknown dependency "redis"`},
+		{"cycle", []Task{task("a", "b"), task("b", "a")}, "dependency cycle: a -> b -> a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Config{Tasks: tt.tasks}).validateDependencies()
+			if tt.err == "" && err != nil {
+				t.Errorf("validateDependencies: %v", err)
+			}
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("validateDependencies error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StatusRange is an inclusive range of HTTP status codes.
+type StatusRange struct {
+	Min, Max int
+}
+
+// StatusList is a set of accepted HTTP status codes. In the config it is a
+// single code, a range ("200-299", "2xx") or a list of those.
+type StatusList []StatusRange
+
+// Match reports whether code is in the list. An empty list accepts any 2xx
+// or 3xx status.
+func (l StatusList) Match(code int) bool {
+	if len(l) == 0 {
+		return code >= 200 && code < 400
+	}
+	for _, r := range l {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+func (l StatusList) String() string {
+	if len(l) == 0 {
+		return "200-399"
+	}
+	parts := make([]string, len(l))
+	for i, r := range l {
+		if r.Min == r.Max {
+			parts[i] = strconv.Itoa(r.Min)
+		} else {
+			parts[i] = fmt.Sprintf("%d-%d", r.Min, r.Max)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (l *StatusList) UnmarshalYAML(value *yaml.Node) error {
+	nodes := []*yaml.Node{value}
+	if value.Kind == yaml.SequenceNode {
+		nodes = value.Content
+	}
+
+	var list StatusList
+	for _, node := range nodes {
+		if node.Kind != yaml.ScalarNode {
+			return fmt.Errorf("line %d: expected_status must be a code, a range or a list of those", node.Line)
+		}
+		ranges, err := parseStatus(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		list = append(list, ranges...)
+	}
+	*l = list
+	return nil
+}
+
+func (l *StatusList) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		items = []json.RawMessage{data}
+	}
+
+	var list StatusList
+	for _, item := range items {
+		var value string
+		if err := json.Unmarshal(item, &value); err != nil {
+			// Not a string, must be a number
+			var code int
+			if err := json.Unmarshal(item, &code); err != nil {
+				return fmt.Errorf("expected_status must be a code, a range or a list of those")
+			}
+			value = strconv.Itoa(code)
+		}
+		ranges, err := parseStatus(value)
+		if err != nil {
+			return err
+		}
+		list = append(list, ranges...)
+	}
+	*l = list
+	return nil
+}
+
+// parseStatus parses "200", "200-299", "2xx" or a comma-separated list of those.
+func parseStatus(s string) ([]StatusRange, error) {
+	var ranges []StatusRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+
+		var r StatusRange
+		var err error
+		switch {
+		case len(part) == 3 && strings.HasSuffix(part, "xx"):
+			var class int
+			class, err = strconv.Atoi(part[:1])
+			r = StatusRange{Min: class * 100, Max: class*100 + 99}
+		case strings.Contains(part, "-"):
+			lo, hi, _ := strings.Cut(part, "-")
+			r.Min, err = strconv.Atoi(strings.TrimSpace(lo))
+			if err == nil {
+				r.Max, err = strconv.Atoi(strings.TrimSpace(hi))
+			}
+		default:
+			r.Min, err = strconv.Atoi(part)
+			r.Max = r.Min
+		}
+		if err != nil || r.Min < 100 || r.Max > 599 || r.Min > r.Max {
+			return nil, fmt.Errorf("invalid expected_status %q", part)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []StatusRange
+		err  bool
+	}{
+		{"200", []StatusRange{{200, 200}}, false},
+		{"200-299", []StatusRange{{200, 299}}, false},
+		{" 200 - 204 ", []StatusRange{{200, 204}}, false},
+		{"2xx", []StatusRange{{200, 299}}, false},
+		{"5XX", []StatusRange{{500, 599}}, false},
+		{"200, 3xx,404", []StatusRange{{200, 200}, {300, 399}, {404, 404}}, false},
+		{"", nil, true},
+		{"abc", nil, true},
+		{"99", nil, true},
+		{"600", nil, true},
+		{"6xx", nil, true},
+		{"xxx", nil, true},
+		{"299-200", nil, true},
+		{"200-", nil, true},
+		{"200,,204", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseStatus(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("parseStatus error = %v, want error %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStatus = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusListUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		json string
+		want StatusList
+		err  bool
+	}{
+		{"code", `200`, `200`, StatusList{{200, 200}}, false},
+		{"range", `"200-204"`, `"200-204"`, StatusList{{200, 204}}, false},
+		{"class", `2xx`, `"2xx"`, StatusList{{200, 299}}, false},
+		{"comma list", `"200, 404"`, `"200, 404"`, StatusList{{200, 200}, {404, 404}}, false},
+		{"list", `[200, "3xx"]`, `[200, "3xx"]`, StatusList{{200, 200}, {300, 399}}, false},
+		{"invalid code", `700`, `700`, nil, true},
+		{"invalid type", `{code: 200}`, `{"code": 200}`, nil, true},
+		{"nested list", `[[200]]`, `[[200]]`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromYAML StatusList
+			err := yaml.Unmarshal([]byte(tt.yaml), &fromYAML)
+			if (err != nil) != tt.err {
+				t.Errorf("YAML error = %v, want error %v", err, tt.err)
+			} else if !tt.err && !reflect.DeepEqual(fromYAML, tt.want) {
+				t.Errorf("YAML = %v, want %v", fromYAML, tt.want)
+			}
+
+			var fromJSON StatusList
+			err = json.Unmarshal([]byte(tt.json), &fromJSON)
+			if (err != nil) != tt.err {
+				t.Errorf("JSON error = %v, want error %v", err, tt.err)
+			} else if !tt.err && !reflect.DeepEqual(fromJSON, tt.want) {
+				t.Errorf("JSON = %v, want %v", fromJSON, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusListMatch(t *testing.T) {
+	tests := []struct {
+		list StatusList
+		code int
+		want bool
+	}{
+		{nil, 200, true},
+		{nil, 301, true},
+		{nil, 404, false},
+		{StatusList{{200, 200}, {500, 599}}, 503, true},
+		{StatusList{{200, 200}, {500, 599}}, 201, false},
+	}
+	for _, tt := range tests {
+		if got := tt.list.Match(tt.code); got != tt.want {
+			t.Errorf("%v.Match(%d) = %v, want %v", tt.list, tt.code, got, tt.want)
+		}
+	}
+}
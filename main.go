@@ -60,7 +60,10 @@ func main() {
 				if event.Has(fsnotify.Write) {
 					// Reload config
 					newCfg, err := config.LoadConfig(configPath)
-					if err == nil {
+					if err != nil {
+						// Keep running with the old config
+						p.Send(ui.ConfigErrorMsg{Err: err})
+					} else {
 						p.Send(ui.ConfigChangedMsg(newCfg))
 					}
 				}
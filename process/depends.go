@@ -0,0 +1,190 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+// errWaitAborted is returned by StartAfter when Stop or Start is called while
+// the task waits for its dependencies.
+var errWaitAborted = errors.New("dependency wait aborted")
+
+// Registry finds tasks by name for resolving dependencies. The set of tasks
+// changes on hot reload; waits look their dependencies up again then.
+type Registry struct {
+	mu      sync.Mutex
+	byName  map[string]*Process
+	changed chan struct{} // Closed and replaced on every Set
+}
+
+// NewRegistry creates a registry holding procs.
+func NewRegistry(procs []*Process) *Registry {
+	r := &Registry{changed: make(chan struct{})}
+	r.Set(procs)
+	return r
+}
+
+// Set replaces the tasks in the registry and wakes up waiting dependents.
+func (r *Registry) Set(procs []*Process) {
+	byName := make(map[string]*Process, len(procs))
+	for _, p := range procs {
+		byName[p.Config.Name] = p
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byName = byName
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Lookup returns the task called name, or nil.
+func (r *Registry) Lookup(name string) *Process {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.byName[name]
+}
+
+// Changed returns a channel that is closed on the next Set.
+func (r *Registry) Changed() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.changed
+}
+
+// StartAfter waits for the task's dependencies to meet their depends_on
+// condition and starts it. While waiting the task is Blocked; it becomes
+// DependencyFailed if a wait_timeout expires or a dependency fails.
+// Dependencies missing from tasks are skipped.
+func (p *Process) StartAfter(tasks *Registry) error {
+	p.mu.Lock()
+	p.stoppedByUser = false
+	p.abortBackoffLocked()
+	p.abortWaitLocked()
+	if p.aliveLocked() {
+		p.mu.Unlock()
+		return ErrAlreadyRunning
+	}
+	cancel := make(chan struct{})
+	p.cancelWait = cancel
+	p.mu.Unlock()
+
+	waitStart := time.Now()
+	for _, dep := range p.Config.DependsOn {
+		p.mu.Lock()
+		if p.cancelWait != cancel {
+			p.mu.Unlock()
+			return errWaitAborted
+		}
+		p.blockedOn = dep.Name
+		_ = p.setStateLocked(StateBlocked, nil)
+		p.mu.Unlock()
+
+		if err := waitForDependency(tasks, dep, waitStart, cancel); err != nil {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.cancelWait != cancel {
+				return errWaitAborted
+			}
+			p.cancelWait = nil
+			_ = p.setStateLocked(StateDependencyFailed, err)
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancelWait != cancel {
+		return errWaitAborted
+	}
+	p.cancelWait = nil
+	p.blockedOn = ""
+	return p.startLocked()
+}
+
+// waitForDependency blocks until the task named by dep meets its condition.
+// The task is looked up again whenever the registry changes, so a dependency
+// replaced on hot reload is followed and a removed one no longer waited for.
+// It fails when the dependency fails, or when dep's wait_timeout has passed
+// since waitStart.
+func waitForDependency(tasks *Registry, dep config.Dependency, waitStart time.Time, cancel chan struct{}) error {
+	var timeout <-chan time.Time
+	if dep.WaitTimeout > 0 {
+		limit := time.Duration(dep.WaitTimeout) * time.Millisecond
+		timer := time.NewTimer(time.Until(waitStart.Add(limit)))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		// Grab the channels before checking to not miss a change in between
+		reloaded := tasks.Changed()
+		d := tasks.Lookup(dep.Name)
+		if d == nil {
+			return nil
+		}
+		changed := d.Changed()
+		if d.Satisfies(dep.Condition) {
+			return nil
+		}
+		// A run that already ended after the wait began counts as started
+		if dep.Condition == config.ConditionStarted && d.startedSince(waitStart) {
+			return nil
+		}
+		if err := d.dependencyErr(dep); err != nil {
+			return err
+		}
+		select {
+		case <-changed:
+		case <-reloaded:
+		case <-cancel:
+			return errWaitAborted
+		case <-timeout:
+			return fmt.Errorf("dependency %q timed out after %s", dep.Name, time.Duration(dep.WaitTimeout)*time.Millisecond)
+		}
+	}
+}
+
+// dependencyErr reports whether the task failed in a way that keeps it from
+// ever meeting dep's condition on its own.
+func (p *Process) dependencyErr(dep config.Dependency) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch p.state {
+	case StateFailed, StateDependencyFailed:
+		if p.err != nil {
+			return fmt.Errorf("dependency %q failed: %w", dep.Name, p.err)
+		}
+		return fmt.Errorf("dependency %q failed", dep.Name)
+	case StateExited:
+		wantCompleted := dep.Condition == config.ConditionCompleted || dep.Condition == "" && p.Config.Type == config.TaskOneshot
+		if wantCompleted && !p.completedLocked() {
+			return fmt.Errorf("dependency %q did not complete successfully", dep.Name)
+		}
+	}
+	return nil
+}
+
+// abortWaitLocked cancels a pending StartAfter and reports whether one was
+// pending. The caller must hold p.mu.
+func (p *Process) abortWaitLocked() bool {
+	if p.cancelWait == nil {
+		return false
+	}
+	close(p.cancelWait)
+	p.cancelWait = nil
+	p.blockedOn = ""
+	return true
+}
+
+// BlockedOn returns the dependency the task is waiting for while Blocked.
+func (p *Process) BlockedOn() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.blockedOn
+}
@@ -0,0 +1,147 @@
+//go:build !windows
+
+package process
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+// waitState waits until p leaves state from, and returns the new state.
+func waitState(t *testing.T, p *Process, from State) State {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		changed := p.Changed()
+		if state := p.State(); state != from {
+			return state
+		}
+		select {
+		case <-changed:
+		case <-deadline:
+			t.Fatalf("task %q still %s", p.Config.Name, from)
+		}
+	}
+}
+
+func TestStartAfterFollowsReload(t *testing.T) {
+	tests := []struct {
+		name   string
+		reload func(t *testing.T, tasks *Registry, app *Process)
+	}{
+		{"dependency replaced", func(t *testing.T, tasks *Registry, app *Process) {
+			db := NewProcess(config.Task{Name: "db", Args: []string{"sleep", "300"}})
+			tasks.Set([]*Process{db, app})
+			if err := db.Start(); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			t.Cleanup(func() { _ = db.Kill() })
+		}},
+		{"dependency removed", func(t *testing.T, tasks *Registry, app *Process) {
+			tasks.Set([]*Process{app})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := NewProcess(config.Task{Name: "db", Args: []string{"sleep", "300"}})
+			app := NewProcess(config.Task{
+				Name:      "app",
+				Args:      []string{"sleep", "300"},
+				DependsOn: config.Dependencies{{Name: "db", Condition: config.ConditionStarted}},
+			})
+			tasks := NewRegistry([]*Process{db, app})
+			t.Cleanup(func() { _ = app.Kill() })
+
+			done := make(chan error, 1)
+			go func() { done <- app.StartAfter(tasks) }()
+			waitState(t, app, StatePending)
+			if state := app.State(); state != StateBlocked {
+				t.Fatalf("state = %s, want %s", state, StateBlocked)
+			}
+
+			tt.reload(t, tasks, app)
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("StartAfter: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("still %s after reload", app.State())
+			}
+			if state := app.State(); state != StateRunning {
+				t.Errorf("state = %s, want %s", state, StateRunning)
+			}
+		})
+	}
+}
+
+func TestStartedCondition(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string // db command
+	}{
+		{"service", []string{"sleep", "300"}},
+		{"exits right away", []string{"true"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := NewProcess(config.Task{Name: "db", Args: tt.args})
+			app := NewProcess(config.Task{
+				Name:      "app",
+				Args:      []string{"sleep", "300"},
+				DependsOn: config.Dependencies{{Name: "db", Condition: config.ConditionStarted}},
+			})
+			t.Cleanup(func() { _ = db.Kill(); _ = app.Kill() })
+
+			// A run that ended before the wait doesn't count
+			if err := db.Start(); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			_ = db.Stop()
+
+			done := make(chan error, 1)
+			go func() { done <- app.StartAfter(NewRegistry([]*Process{db, app})) }()
+			waitState(t, app, StatePending)
+			time.Sleep(100 * time.Millisecond)
+			if state := app.State(); state != StateBlocked {
+				t.Fatalf("app is %s with db %s, want %s", state, db.State(), StateBlocked)
+			}
+
+			if err := db.Start(); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("StartAfter: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("app still %s after db started", app.State())
+			}
+		})
+	}
+}
+
+func TestFailureWithRestartIsNotFatal(t *testing.T) {
+	db := NewProcess(config.Task{
+		Name:           "db",
+		Args:           []string{"sh", "-c", "sleep 0.1; exit 1"},
+		Restart:        config.RestartOnFailure,
+		RestartBackoff: 1000,
+	})
+	t.Cleanup(func() { _ = db.Stop() })
+	if err := db.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// A dependent woken by the exit must find the restart already pending
+	state := waitState(t, db, StateRunning)
+	if state != StateBackoff {
+		t.Errorf("db is %s after its run failed, want %s", state, StateBackoff)
+	}
+	if err := db.dependencyErr(config.Dependency{Name: "db"}); err != nil {
+		t.Errorf("dependencyErr = %v while a restart is pending", err)
+	}
+}
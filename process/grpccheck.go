@@ -0,0 +1,73 @@
+package process
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// checkGRPC calls grpc.health.v1.Health/Check on the target and passes if the
+// service reports SERVING.
+func (p *Process) checkGRPC(hc *config.HealthCheck, timeout time.Duration) (bool, string) {
+	creds, err := grpcCredentials(hc)
+	if err != nil {
+		return false, err.Error()
+	}
+	conn, err := grpc.NewClient(hc.Target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return false, err.Error()
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: hc.Service})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.DeadlineExceeded:
+			return false, fmt.Sprintf("timed out after %s", timeout)
+		case codes.Unimplemented:
+			return false, "server does not implement grpc.health.v1"
+		case codes.NotFound:
+			return false, fmt.Sprintf("unknown service %q", hc.Service)
+		}
+		return false, status.Convert(err).Message()
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return false, resp.GetStatus().String()
+	}
+	return true, resp.GetStatus().String()
+}
+
+// grpcCredentials returns plaintext credentials, or TLS ones if tls is set.
+func grpcCredentials(hc *config.HealthCheck) (credentials.TransportCredentials, error) {
+	if !hc.TLS {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: hc.InsecureSkipVerify}
+	if hc.CAFile != "" {
+		pem, err := os.ReadFile(hc.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca_file %s", hc.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
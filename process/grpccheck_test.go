@@ -0,0 +1,58 @@
+package process
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// serveGRPC runs a gRPC server on a free local port until the test ends.
+func serveGRPC(t *testing.T, register func(*grpc.Server)) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	register(server)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestCheckGRPC(t *testing.T) {
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("api", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("worker", healthpb.HealthCheckResponse_NOT_SERVING)
+	withHealth := serveGRPC(t, func(s *grpc.Server) { healthpb.RegisterHealthServer(s, healthServer) })
+	withoutHealth := serveGRPC(t, func(*grpc.Server) {})
+
+	tests := []struct {
+		name    string
+		target  string
+		service string
+		healthy bool
+		detail  string
+	}{
+		{"server", withHealth, "", true, "SERVING"},
+		{"serving", withHealth, "api", true, "SERVING"},
+		{"not serving", withHealth, "worker", false, "NOT_SERVING"},
+		{"unknown service", withHealth, "billing", false, `unknown service "billing"`},
+		{"no health service", withoutHealth, "", false, "server does not implement grpc.health.v1"},
+	}
+	p := &Process{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := &config.HealthCheck{Type: config.HealthGRPC, Target: tt.target, Service: tt.service}
+			healthy, detail := p.checkGRPC(hc, 2*time.Second)
+			if healthy != tt.healthy || detail != tt.detail {
+				t.Errorf("checkGRPC = %v, %q; want %v, %q", healthy, detail, tt.healthy, tt.detail)
+			}
+		})
+	}
+}
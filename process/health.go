@@ -0,0 +1,349 @@
+package process
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+// maxProbeOutput caps how much exec probe output is kept for display.
+const maxProbeOutput = 1024
+
+// Health check defaults.
+const (
+	defaultFailureThreshold = 3
+	defaultSuccessThreshold = 1
+)
+
+// Flap detection: a task whose health changed flapFlips times within
+// flapWindow is flagged as flapping.
+const (
+	flapFlips  = 4
+	flapWindow = 5 * time.Minute
+)
+
+// defaultUnhealthyTimeout is how long a task must stay unhealthy before
+// on_unhealthy applies.
+const defaultUnhealthyTimeout = 30 * time.Second
+
+// probeHistorySize is how many probe results are kept per task.
+const probeHistorySize = 20
+
+// Probe is the result of a single health probe.
+type Probe struct {
+	At       time.Time
+	Healthy  bool
+	Detail   string
+	Duration time.Duration
+}
+
+// monitorHealth probes the service periodically until the run ends (done is
+// closed). Failures during start_period keep the task Starting; after that
+// failure_threshold consecutive failures make it Unhealthy and
+// success_threshold consecutive successes make it Healthy.
+func (p *Process) monitorHealth(done chan struct{}) {
+	hc := p.Config.HealthCheck
+	interval := time.Duration(hc.Interval) * time.Millisecond
+	if interval == 0 {
+		interval = 2000 * time.Millisecond
+	} // Default 2s
+	startPeriod := time.Duration(hc.StartPeriod) * time.Millisecond
+	failureThreshold := hc.FailureThreshold
+	if failureThreshold <= 0 {
+		failureThreshold = defaultFailureThreshold
+	}
+	successThreshold := hc.SuccessThreshold
+	if successThreshold <= 0 {
+		successThreshold = defaultSuccessThreshold
+	}
+
+	started := time.Now()
+	successes, failures := 0, 0
+	for {
+		probeStart := time.Now()
+		healthy, detail := p.checkHealth()
+		duration := time.Since(probeStart)
+
+		p.mu.Lock()
+		select {
+		case <-done:
+			p.mu.Unlock()
+			return
+		default:
+		}
+		p.recordProbeLocked(Probe{At: probeStart, Healthy: healthy, Detail: detail, Duration: duration})
+		p.healthDetail = detail
+
+		switch {
+		case healthy:
+			successes, failures = successes+1, 0
+		case p.state == StateStarting && time.Since(started) < startPeriod:
+			// Still booting, failures don't count yet
+			successes = 0
+		default:
+			successes, failures = 0, failures+1
+		}
+		// Transitions are rejected once the process is stopping
+		switch {
+		case healthy && successes >= successThreshold:
+			p.setHealthLocked(StateHealthy)
+		case !healthy && failures >= failureThreshold:
+			p.setHealthLocked(StateUnhealthy)
+		}
+		note, restart := p.unhealthyActionLocked()
+		p.mu.Unlock()
+
+		if note != "" {
+			p.notice("%s", note)
+		}
+		if restart {
+			// Stopping ends this monitor
+			go p.restartUnhealthy()
+			return
+		}
+
+		select {
+		case <-done:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// setHealthLocked moves the process to Healthy or Unhealthy and records the
+// flip for flap detection. The caller must hold p.mu.
+func (p *Process) setHealthLocked(to State) error {
+	from := p.state
+	if err := p.setStateLocked(to, nil); err != nil {
+		return err
+	}
+	if from != to && (from == StateHealthy || from == StateUnhealthy) {
+		p.healthFlips = append(p.healthFlips, time.Now())
+	}
+	switch {
+	case to == StateHealthy:
+		p.unhealthyStreak = 0
+		p.unhealthyGaveUp = false
+	case from != to:
+		p.unhealthySince = time.Now()
+	}
+	return nil
+}
+
+// unhealthyActionLocked applies on_unhealthy to a task that has been
+// unhealthy for longer than unhealthy_timeout. It returns a note for the
+// task's log and whether to restart it. The caller must hold p.mu.
+func (p *Process) unhealthyActionLocked() (string, bool) {
+	hc := p.Config.HealthCheck
+	if hc.OnUnhealthy != config.OnUnhealthyRestart || p.state != StateUnhealthy {
+		return "", false
+	}
+	unhealthyFor := time.Since(p.unhealthySince)
+	if unhealthyFor < millis(hc.UnhealthyTimeout, defaultUnhealthyTimeout) {
+		return "", false
+	}
+
+	if hc.MaxUnhealthyRestarts > 0 && p.unhealthyStreak >= hc.MaxUnhealthyRestarts {
+		if p.unhealthyGaveUp {
+			return "", false
+		}
+		p.unhealthyGaveUp = true
+		return fmt.Sprintf("still unhealthy after %d restarts, giving up", p.unhealthyStreak), false
+	}
+
+	p.unhealthyStreak++
+	p.unhealthyRestarts++
+	if unhealthyFor >= time.Second {
+		unhealthyFor = unhealthyFor.Round(time.Second)
+	}
+	return fmt.Sprintf("unhealthy for %s, restarting (%d)", unhealthyFor.Round(time.Millisecond), p.unhealthyStreak), true
+}
+
+// UnhealthyRestarts returns how many times on_unhealthy restarted the task.
+func (p *Process) UnhealthyRestarts() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.unhealthyRestarts
+}
+
+// recordProbeLocked appends to the probe history. The caller must hold p.mu.
+func (p *Process) recordProbeLocked(probe Probe) {
+	p.probes = append(p.probes, probe)
+	if len(p.probes) > probeHistorySize {
+		p.probes = p.probes[len(p.probes)-probeHistorySize:]
+	}
+}
+
+// Probes returns the most recent health probe results, oldest first.
+func (p *Process) Probes() []Probe {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Probe(nil), p.probes...)
+}
+
+// Flapping reports whether the task's health changed flapFlips times or
+// more within the last flapWindow.
+func (p *Process) Flapping() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cutoff := time.Now().Add(-flapWindow)
+	i := 0
+	for i < len(p.healthFlips) && p.healthFlips[i].Before(cutoff) {
+		i++
+	}
+	p.healthFlips = p.healthFlips[i:]
+	return len(p.healthFlips) >= flapFlips
+}
+
+// HealthDetail returns what the last health probe reported: the output of an
+// exec probe, or why a probe failed.
+func (p *Process) HealthDetail() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.healthDetail
+}
+
+// checkHealth runs one probe and reports whether it passed, with a detail
+// for display.
+func (p *Process) checkHealth() (bool, string) {
+	hc := p.Config.HealthCheck
+	if hc == nil {
+		return true, ""
+	}
+
+	timeout := time.Duration(hc.Timeout) * time.Millisecond
+	if timeout == 0 {
+		timeout = 1000 * time.Millisecond
+	}
+
+	switch hc.Type {
+	case config.HealthTCP:
+		conn, err := net.DialTimeout("tcp", hc.Target, timeout)
+		if err != nil {
+			return false, requestError(err, timeout)
+		}
+		conn.Close()
+		return true, ""
+	case config.HealthHTTP:
+		return p.checkHTTP(hc, timeout)
+	case config.HealthGRPC:
+		return p.checkGRPC(hc, timeout)
+	case config.HealthExec:
+		return p.checkExec(hc.Command, timeout)
+	}
+	return false, fmt.Sprintf("unknown health check type %q", hc.Type)
+}
+
+// compileHealthPatterns prepares the patterns of log and HTTP health checks.
+// LoadConfig has validated them already.
+func (p *Process) compileHealthPatterns() {
+	hc := p.Config.HealthCheck
+	if hc == nil {
+		return
+	}
+	switch hc.Type {
+	case config.HealthLog:
+		p.healthPattern, _ = regexp.Compile(hc.Pattern)
+		if hc.UnhealthyPattern != "" {
+			p.unhealthyPattern, _ = regexp.Compile(hc.UnhealthyPattern)
+		}
+	case config.HealthHTTP:
+		if hc.BodyRegex != "" {
+			p.bodyPattern, _ = regexp.Compile(hc.BodyRegex)
+		}
+	}
+}
+
+// sgrPattern matches color escape sequences.
+var sgrPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// StripColors removes color escape sequences from a line of output.
+func StripColors(text string) string {
+	return sgrPattern.ReplaceAllString(text, "")
+}
+
+// matchHealthLine applies a log health check to a line of task output.
+func (p *Process) matchHealthLine(text string) {
+	if p.healthPattern == nil {
+		return
+	}
+	// Colors would get in the way of the patterns
+	text = StripColors(text)
+
+	var to State
+	switch {
+	case p.unhealthyPattern != nil && p.unhealthyPattern.MatchString(text):
+		to = StateUnhealthy
+	case p.healthPattern.MatchString(text):
+		to = StateHealthy
+	default:
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Lines still arriving after the task exited or during Stop are ignored
+	if p.setHealthLocked(to) == nil {
+		p.healthDetail = text
+	}
+}
+
+// checkExec runs command in the task's directory and environment. It passes
+// if the command exits with status 0; the command's output is the detail.
+// Probes still running after timeout are killed with their children.
+func (p *Process) checkExec(command string, timeout time.Duration) (bool, string) {
+	argv := p.commandArgv(command)
+	if len(argv) == 0 {
+		return false, "empty health check command"
+	}
+
+	c := p.command(argv)
+	setProcessGroup(c)
+	var out bytes.Buffer
+	c.Stdout = &out
+	c.Stderr = &out
+	// Don't wait forever for output from children that escaped the kill
+	c.WaitDelay = time.Second
+	if err := c.Start(); err != nil {
+		return false, err.Error()
+	}
+
+	waitErr := make(chan error, 1)
+	go func() { waitErr <- c.Wait() }()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case err = <-waitErr:
+	case <-timer.C:
+		_ = signalGroup(c.Process, os.Kill)
+		<-waitErr
+		return false, fmt.Sprintf("timed out after %s", timeout)
+	}
+
+	detail := strings.TrimSpace(out.String())
+	if len(detail) > maxProbeOutput {
+		detail = "..." + detail[len(detail)-maxProbeOutput:]
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if detail == "" {
+			detail = err.Error()
+		} else if !errors.As(err, &exitErr) {
+			detail = err.Error() + ": " + detail
+		}
+		return false, detail
+	}
+	return true, detail
+}
@@ -0,0 +1,58 @@
+//go:build !windows
+
+package process
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+// closedPort returns a local address nothing listens on.
+func closedPort(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
+}
+
+func TestStartPeriodFailuresDontCount(t *testing.T) {
+	p := NewProcess(config.Task{
+		Name: "api",
+		Args: []string{"sleep", "300"},
+		HealthCheck: &config.HealthCheck{
+			Type:        config.HealthTCP,
+			Target:      closedPort(t),
+			Interval:    200,
+			Timeout:     50,
+			StartPeriod: 400,
+		},
+	})
+	if err := p.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer p.Kill()
+	start := time.Now()
+
+	// Probes at 0 and 200ms fall into the start period; those at 400 and
+	// 600ms are the first two failures that count
+	time.Sleep(700*time.Millisecond - time.Since(start))
+	if state := p.State(); state != StateStarting {
+		t.Fatalf("state = %s after %s, want %s", state, time.Since(start), StateStarting)
+	}
+
+	// The third failure after the period makes it Unhealthy
+	deadline := time.Now().Add(2 * time.Second)
+	for p.State() != StateUnhealthy {
+		if time.Now().After(deadline) {
+			t.Fatalf("state = %s, want %s", p.State(), StateUnhealthy)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
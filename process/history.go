@@ -0,0 +1,82 @@
+package process
+
+import (
+	"os"
+	"time"
+)
+
+// historySize is the number of past runs kept per process.
+const historySize = 10
+
+// Run records a single execution of a process.
+type Run struct {
+	StartedAt time.Time
+	StoppedAt time.Time
+	ExitCode  int    // -1 if terminated by a signal
+	Signal    string // Terminating signal, e.g. "SIGKILL"
+	Requested bool   // Ended by Stop/Kill rather than on its own
+	Err       error
+}
+
+// Uptime returns how long the run lasted.
+func (r Run) Uptime() time.Duration {
+	return r.StoppedAt.Sub(r.StartedAt)
+}
+
+// recordRunLocked appends a finished run to the history, dropping the oldest
+// entry when full. The caller must hold p.mu.
+func (p *Process) recordRunLocked(state *os.ProcessState, requested bool, err error) Run {
+	run := Run{
+		StartedAt: p.startedAt,
+		StoppedAt: time.Now(),
+		ExitCode:  -1,
+		Signal:    exitSignal(state),
+		Requested: requested,
+		Err:       err,
+	}
+	if state != nil {
+		run.ExitCode = state.ExitCode()
+	}
+
+	p.history = append(p.history, run)
+	if len(p.history) > historySize {
+		p.history = p.history[len(p.history)-historySize:]
+	}
+	return run
+}
+
+// History returns the finished runs, oldest first.
+func (p *Process) History() []Run {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	history := make([]Run, len(p.history))
+	copy(history, p.history)
+	return history
+}
+
+// LastRun returns the most recent finished run.
+func (p *Process) LastRun() (Run, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.history) == 0 {
+		return Run{}, false
+	}
+	return p.history[len(p.history)-1], true
+}
+
+// StartedAt returns when the current or last run started.
+func (p *Process) StartedAt() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.startedAt
+}
+
+// Uptime returns how long the current run has been up, or 0 if not running.
+func (p *Process) Uptime() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.aliveLocked() {
+		return 0
+	}
+	return time.Since(p.startedAt)
+}
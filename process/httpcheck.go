@@ -0,0 +1,169 @@
+package process
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+// maxProbeBody caps how much of a response body is read for body checks.
+const maxProbeBody = 1 << 20
+
+// checkHTTP sends the configured request and checks the status code, body
+// and JSON assertions. On failure the detail says which check failed.
+func (p *Process) checkHTTP(hc *config.HealthCheck, timeout time.Duration) (bool, string) {
+	method := strings.ToUpper(hc.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequest(method, hc.Target, nil)
+	if err != nil {
+		return false, err.Error()
+	}
+	for key, value := range hc.Headers {
+		if strings.EqualFold(key, "Host") {
+			req.Host = value
+		} else {
+			req.Header.Set(key, value)
+		}
+	}
+
+	client := http.Client{
+		Timeout: timeout,
+		// Judge the response of the target itself, a redirect is a status like any other
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: hc.InsecureSkipVerify},
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, requestError(err, timeout)
+	}
+	defer resp.Body.Close()
+
+	if !hc.ExpectedStatus.Match(resp.StatusCode) {
+		return false, fmt.Sprintf("status %s, want %s", resp.Status, hc.ExpectedStatus)
+	}
+	if hc.BodyContains == "" && p.bodyPattern == nil && len(hc.JSON) == 0 {
+		return true, resp.Status
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	if err != nil {
+		return false, requestError(err, timeout)
+	}
+	if hc.BodyContains != "" && !strings.Contains(string(body), hc.BodyContains) {
+		return false, fmt.Sprintf("body does not contain %q", hc.BodyContains)
+	}
+	if p.bodyPattern != nil && !p.bodyPattern.Match(body) {
+		return false, fmt.Sprintf("body does not match /%s/", hc.BodyRegex)
+	}
+	if len(hc.JSON) > 0 {
+		if reason := checkJSON(body, hc.JSON); reason != "" {
+			return false, reason
+		}
+	}
+	return true, resp.Status
+}
+
+// requestError describes why a request failed, without repeating the URL.
+func requestError(err error, timeout time.Duration) string {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Sprintf("timed out after %s", timeout)
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// checkJSON verifies every path -> value assertion against body and returns
+// the first failure, or "" if all pass.
+func checkJSON(body []byte, assertions map[string]string) string {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return "body is not JSON: " + err.Error()
+	}
+
+	paths := make([]string, 0, len(assertions))
+	for path := range assertions {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		want := assertions[path]
+		value, ok := jsonPath(doc, path)
+		if !ok {
+			return fmt.Sprintf("json %s is missing", path)
+		}
+		if got := jsonString(value); got != want {
+			return fmt.Sprintf("json %s is %q, want %q", path, got, want)
+		}
+	}
+	return ""
+}
+
+// jsonPath looks up a dotted path such as "status", "$.checks.db" or
+// "items.0.name" in a decoded JSON document.
+func jsonPath(doc any, path string) (any, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return doc, true
+	}
+
+	current := doc
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			current = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// jsonString formats a decoded JSON value for comparison with the config.
+func jsonString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
@@ -0,0 +1,96 @@
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+func TestJSONPath(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(`{
+		"status": "ok",
+		"checks": {"db": {"up": true, "latency": 1.5}},
+		"items": [{"name": "first"}, {"name": "second"}],
+		"empty": null
+	}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		want  string
+		found bool
+	}{
+		{"status", "ok", true},
+		{"$.status", "ok", true},
+		{".status", "ok", true},
+		{"checks.db.up", "true", true},
+		{"$.checks.db.latency", "1.5", true},
+		{"items.1.name", "second", true},
+		{"items.0", `{"name":"first"}`, true},
+		{"empty", "null", true},
+		{"missing", "", false},
+		{"checks.cache.up", "", false},
+		{"items.2.name", "", false},
+		{"items.-1", "", false},
+		{"items.first", "", false},
+		{"status.code", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			value, found := jsonPath(doc, tt.path)
+			if found != tt.found {
+				t.Fatalf("jsonPath found = %v, want %v", found, tt.found)
+			}
+			if got := jsonString(value); found && got != tt.want {
+				t.Errorf("jsonPath = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	for _, path := range []string{"", "$", "$."} {
+		if value, found := jsonPath(doc, path); !found || value == nil {
+			t.Errorf("jsonPath(%q) did not return the document", path)
+		}
+	}
+}
+
+func TestCheckHTTPRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			fmt.Fprint(w, "please log in")
+			return
+		}
+		http.Redirect(w, r, "/login", http.StatusFound)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		hc      config.HealthCheck
+		healthy bool
+		detail  string
+	}{
+		{"default", config.HealthCheck{}, true, "302 Found"},
+		{"expected redirect", config.HealthCheck{ExpectedStatus: config.StatusList{{Min: 302, Max: 302}}}, true, "302 Found"},
+		{"expected 200", config.HealthCheck{ExpectedStatus: config.StatusList{{Min: 200, Max: 200}}}, false, "status 302 Found, want 200"},
+		{"body of the redirect", config.HealthCheck{BodyContains: "please log in"}, false, `body does not contain "please log in"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := tt.hc
+			hc.Type, hc.Target = config.HealthHTTP, server.URL+"/"
+			p := &Process{}
+			healthy, detail := p.checkHTTP(&hc, time.Second)
+			if healthy != tt.healthy || detail != tt.detail {
+				t.Errorf("checkHTTP = %v, %q; want %v, %q", healthy, detail, tt.healthy, tt.detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package process
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultMaxLogLines is the log buffer capacity when max_log_lines is not set.
+const DefaultMaxLogLines = 10000
+
+// Stream identifies where a log line came from.
+type Stream int
+
+const (
+	StreamStdout Stream = iota
+	StreamStderr
+	StreamSystem // Lines written by DevDeck itself
+)
+
+func (s Stream) String() string {
+	switch s {
+	case StreamStdout:
+		return "stdout"
+	case StreamStderr:
+		return "stderr"
+	default:
+		return "devdeck"
+	}
+}
+
+// LogLine is a single line of output with its metadata.
+type LogLine struct {
+	Seq    uint64 // Assigned by LogBuffer.Append, increases by one per line
+	Time   time.Time
+	Stream Stream
+	Text   string
+}
+
+// LogBuffer is a fixed-capacity, line-oriented ring buffer. Once full, every
+// append overwrites the oldest line. It is safe for concurrent use.
+type LogBuffer struct {
+	mu    sync.RWMutex
+	lines []LogLine
+	start int    // Index of the oldest line
+	count int    // Number of stored lines
+	next  uint64 // Sequence number of the next appended line
+}
+
+// NewLogBuffer creates a buffer holding at most capacity lines.
+func NewLogBuffer(capacity int) *LogBuffer {
+	if capacity <= 0 {
+		capacity = DefaultMaxLogLines
+	}
+	return &LogBuffer{lines: make([]LogLine, capacity)}
+}
+
+// Append stores a line, assigning its sequence number, and returns it.
+func (b *LogBuffer) Append(line LogLine) LogLine {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	line.Seq = b.next
+	b.next++
+	if line.Time.IsZero() {
+		line.Time = time.Now()
+	}
+
+	capacity := len(b.lines)
+	if b.count < capacity {
+		b.lines[(b.start+b.count)%capacity] = line
+		b.count++
+	} else {
+		b.lines[b.start] = line
+		b.start = (b.start + 1) % capacity
+	}
+	return line
+}
+
+// Len returns the number of stored lines.
+func (b *LogBuffer) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.count
+}
+
+// FirstSeq returns the sequence number of the oldest stored line.
+func (b *LogBuffer) FirstSeq() uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.next - uint64(b.count)
+}
+
+// Slice returns up to n lines starting at index from (0 is the oldest line).
+func (b *LogBuffer) Slice(from, n int) []LogLine {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if from < 0 {
+		from = 0
+	}
+	if from+n > b.count {
+		n = b.count - from
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	out := make([]LogLine, n)
+	capacity := len(b.lines)
+	for i := 0; i < n; i++ {
+		out[i] = b.lines[(b.start+from+i)%capacity]
+	}
+	return out
+}
+
+// Tail returns the newest n lines.
+func (b *LogBuffer) Tail(n int) []LogLine {
+	total := b.Len()
+	return b.Slice(total-n, n)
+}
+
+// All returns every stored line, oldest first.
+func (b *LogBuffer) All() []LogLine {
+	return b.Slice(0, b.Len())
+}
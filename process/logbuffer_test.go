@@ -0,0 +1,117 @@
+package process
+
+import (
+	"strconv"
+	"testing"
+)
+
+// filledBuffer returns a buffer of the given capacity after appending lines
+// "0" to "n-1".
+func filledBuffer(capacity, n int) *LogBuffer {
+	b := NewLogBuffer(capacity)
+	for i := 0; i < n; i++ {
+		b.Append(LogLine{Text: strconv.Itoa(i)})
+	}
+	return b
+}
+
+// texts returns the text of every line, joined for easy comparison.
+func texts(lines []LogLine) string {
+	s := ""
+	for i, line := range lines {
+		if i > 0 {
+			s += " "
+		}
+		s += line.Text
+	}
+	return s
+}
+
+func TestLogBufferWraparound(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		appended int
+		all      string
+		firstSeq uint64
+	}{
+		{"empty", 3, 0, "", 0},
+		{"partial", 3, 2, "0 1", 0},
+		{"full", 3, 3, "0 1 2", 0},
+		{"wrapped once", 3, 4, "1 2 3", 1},
+		{"wrapped to start", 3, 6, "3 4 5", 3},
+		{"wrapped many times", 3, 10, "7 8 9", 7},
+		{"capacity one", 1, 5, "4", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := filledBuffer(tt.capacity, tt.appended)
+			all := b.All()
+			if got := texts(all); got != tt.all {
+				t.Errorf("All() = %q, want %q", got, tt.all)
+			}
+			if got := b.FirstSeq(); got != tt.firstSeq {
+				t.Errorf("FirstSeq() = %d, want %d", got, tt.firstSeq)
+			}
+			for i, line := range all {
+				if line.Seq != tt.firstSeq+uint64(i) {
+					t.Errorf("line %d has Seq %d, want %d", i, line.Seq, tt.firstSeq+uint64(i))
+				}
+			}
+		})
+	}
+}
+
+func TestLogBufferDefaultCapacity(t *testing.T) {
+	b := filledBuffer(0, DefaultMaxLogLines+1)
+	if n := b.Len(); n != DefaultMaxLogLines {
+		t.Errorf("Len() = %d, want %d", n, DefaultMaxLogLines)
+	}
+}
+
+func TestLogBufferSlice(t *testing.T) {
+	b := filledBuffer(5, 7) // Holds 2 3 4 5 6
+	tests := []struct {
+		from, n int
+		want    string
+	}{
+		{0, 5, "2 3 4 5 6"},
+		{0, 2, "2 3"},
+		{3, 2, "5 6"},
+		{3, 10, "5 6"},
+		{4, 1, "6"},
+		{5, 1, ""},
+		{9, 3, ""},
+		{2, 0, ""},
+		{2, -1, ""},
+		{-3, 2, "2 3"},
+	}
+	for _, tt := range tests {
+		if got := texts(b.Slice(tt.from, tt.n)); got != tt.want {
+			t.Errorf("Slice(%d, %d) = %q, want %q", tt.from, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestLogBufferTail(t *testing.T) {
+	b := filledBuffer(5, 7) // Holds 2 3 4 5 6
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, ""},
+		{1, "6"},
+		{3, "4 5 6"},
+		{5, "2 3 4 5 6"},
+		{8, "2 3 4 5 6"},
+		{-1, ""},
+	}
+	for _, tt := range tests {
+		if got := texts(b.Tail(tt.n)); got != tt.want {
+			t.Errorf("Tail(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+	if got := texts(NewLogBuffer(5).Tail(3)); got != "" {
+		t.Errorf("Tail on an empty buffer = %q", got)
+	}
+}
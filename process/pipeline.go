@@ -0,0 +1,374 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+// maxLineLength caps a single log line; longer lines are split.
+const maxLineLength = 64 * 1024
+
+// Default terminal size of tty tasks until the UI reports the pane size.
+const (
+	defaultCols = 80
+	defaultRows = 24
+)
+
+// readLines drains r until EOF and emits every line on the given stream. It
+// never blocks on the UI, so the child's pipe can't fill up.
+func (p *Process) readLines(r io.ReadCloser, stream Stream) {
+	defer r.Close()
+
+	reader := bufio.NewReaderSize(r, maxLineLength)
+	var partial []byte
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if len(chunk) > 0 || (err == nil && !isPrefix) {
+			partial = append(partial, chunk...)
+			if !isPrefix || len(partial) >= maxLineLength {
+				p.emit(LogLine{Time: time.Now(), Stream: stream, Text: string(partial)})
+				partial = partial[:0]
+			}
+		}
+		if err != nil {
+			if len(partial) > 0 {
+				p.emit(LogLine{Time: time.Now(), Stream: stream, Text: string(partial)})
+			}
+			p.flushDropped()
+			return
+		}
+	}
+}
+
+// notice emits a DevDeck status line.
+func (p *Process) notice(format string, args ...any) {
+	p.emit(LogLine{Time: time.Now(), Stream: StreamSystem, Text: "[devdeck] " + fmt.Sprintf(format, args...)})
+}
+
+// emit hands a line to the Output channel without blocking. When the channel
+// is full the task's log_overflow policy decides what happens to it.
+func (p *Process) emit(line LogLine) {
+	if line.Stream != StreamSystem {
+		p.matchHealthLine(line.Text)
+	}
+
+	p.pipeMu.Lock()
+	defer p.pipeMu.Unlock()
+
+	// Keep ordering: once spilling, everything goes through the spill file
+	if p.spill != nil {
+		p.spillLocked(line)
+		return
+	}
+
+	if p.dropped > 0 {
+		if !p.trySend(p.droppedMarkerLocked()) {
+			p.overflowLocked(line)
+			return
+		}
+		p.dropped = 0
+	}
+
+	if !p.trySend(line) {
+		p.overflowLocked(line)
+	}
+}
+
+// droppedMarkerLocked returns the line reporting dropped output.
+// The caller must hold p.pipeMu.
+func (p *Process) droppedMarkerLocked() LogLine {
+	return LogLine{Time: time.Now(), Stream: StreamSystem, Text: fmt.Sprintf("[devdeck] %d lines dropped", p.dropped)}
+}
+
+// flushDropped reports lines dropped at the end of the output, which would
+// otherwise wait for the next line. It blocks until the marker is delivered
+// or the task is closed.
+func (p *Process) flushDropped() {
+	p.pipeMu.Lock()
+	if p.dropped == 0 || p.spill != nil {
+		p.pipeMu.Unlock()
+		return
+	}
+	marker := p.droppedMarkerLocked()
+	p.dropped = 0
+	p.pipeMu.Unlock()
+
+	p.send(marker)
+}
+
+// Close releases the output pipeline once the task is stopped and nobody
+// reads Output anymore, e.g. after it was removed from the config. Blocked
+// deliveries give up, later lines are dropped and the spill file is deleted.
+func (p *Process) Close() {
+	p.pipeMu.Lock()
+	defer p.pipeMu.Unlock()
+	select {
+	case <-p.closed:
+		return
+	default:
+	}
+	close(p.closed)
+	if p.spill != nil {
+		p.closeSpillLocked(p.spill)
+	}
+}
+
+// Done returns a channel that is closed by Close. Listeners on Output and
+// Events give up then.
+func (p *Process) Done() <-chan struct{} {
+	return p.closed
+}
+
+// send delivers line, waiting for room in the channel, and reports whether
+// it was delivered before the task was closed.
+func (p *Process) send(line LogLine) bool {
+	select {
+	case p.Output <- line:
+		return true
+	case <-p.closed:
+		return false
+	}
+}
+
+// trySend sends line if the channel has room.
+func (p *Process) trySend(line LogLine) bool {
+	select {
+	case p.Output <- line:
+		return true
+	default:
+		return false
+	}
+}
+
+// overflowLocked applies the overflow policy to a line that didn't fit.
+// The caller must hold p.pipeMu.
+func (p *Process) overflowLocked(line LogLine) {
+	switch p.Config.LogOverflow {
+	case config.OverflowDropNewest:
+		p.dropped++
+	case config.OverflowSpill:
+		p.spillLocked(line)
+	default:
+		// Drop oldest: make room by discarding the head of the queue
+		select {
+		case <-p.Output:
+			p.dropped++
+		default:
+		}
+		if !p.trySend(line) {
+			p.dropped++
+		}
+	}
+}
+
+// spillFile buffers lines on disk while the UI is behind.
+type spillFile struct {
+	file    *os.File
+	backlog int // Lines written but not yet delivered
+}
+
+// spillLocked appends line to the spill file, creating it and its drain
+// goroutine on first use. The caller must hold p.pipeMu.
+func (p *Process) spillLocked(line LogLine) {
+	if p.spill == nil {
+		select {
+		case <-p.closed:
+			p.dropped++
+			return
+		default:
+		}
+		file, err := os.CreateTemp("", "devdeck-spill-*.log")
+		if err != nil {
+			p.dropped++
+			return
+		}
+		p.spill = &spillFile{file: file}
+		go p.drainSpill(p.spill)
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		p.dropped++
+		return
+	}
+	if _, err := p.spill.file.Write(append(data, '\n')); err != nil {
+		p.dropped++
+		return
+	}
+	p.spill.backlog++
+}
+
+// drainSpill replays spilled lines into Output in order, blocking as needed,
+// and removes the file once the backlog is empty or the task is closed.
+func (p *Process) drainSpill(spill *spillFile) {
+	reader, err := os.Open(spill.file.Name())
+	if err != nil {
+		p.pipeMu.Lock()
+		p.closeSpillLocked(spill)
+		p.pipeMu.Unlock()
+		return
+	}
+
+	buf := bufio.NewReader(reader)
+	var partial []byte
+	for {
+		chunk, err := buf.ReadBytes('\n')
+		partial = append(partial, chunk...)
+		if err == io.EOF {
+			p.pipeMu.Lock()
+			if p.spill != spill {
+				p.pipeMu.Unlock()
+				abandonSpill(reader, spill)
+				return
+			}
+			if spill.backlog == 0 {
+				// Caught up, go back to sending directly
+				reader.Close()
+				p.closeSpillLocked(spill)
+				p.pipeMu.Unlock()
+				return
+			}
+			p.pipeMu.Unlock()
+			// Writer is mid-line or ahead of us, wait for more
+			time.Sleep(10 * time.Millisecond)
+			continue
+		} else if err != nil {
+			reader.Close()
+			p.pipeMu.Lock()
+			p.closeSpillLocked(spill)
+			p.pipeMu.Unlock()
+			return
+		}
+
+		var line LogLine
+		if err := json.Unmarshal(partial, &line); err == nil && !p.send(line) {
+			abandonSpill(reader, spill)
+			return
+		}
+		partial = partial[:0]
+
+		p.pipeMu.Lock()
+		spill.backlog--
+		p.pipeMu.Unlock()
+	}
+}
+
+// abandonSpill deletes the file of a spill that Close ended. Close already
+// tried, but Windows refuses while the reader has it open.
+func abandonSpill(reader *os.File, spill *spillFile) {
+	reader.Close()
+	os.Remove(spill.file.Name())
+}
+
+// closeSpillLocked stops spilling and deletes the file, unless that already
+// happened. Lines that were never delivered count as dropped. The caller must
+// hold p.pipeMu.
+func (p *Process) closeSpillLocked(spill *spillFile) {
+	if p.spill != spill {
+		return
+	}
+	p.dropped += spill.backlog
+	p.spill = nil
+	spill.file.Close()
+	os.Remove(spill.file.Name())
+}
+
+// readTerminal drains the pty master, feeding the screen and splitting the
+// output into log lines until the terminal is closed.
+func (p *Process) readTerminal(master io.ReadCloser, screen *Terminal) {
+	defer master.Close()
+
+	// Start every run on a clean screen
+	screen.Write([]byte("\x1bc"))
+
+	buf := make([]byte, 32*1024)
+	var partial []byte
+	for {
+		n, err := master.Read(buf)
+		if n > 0 {
+			screen.Write(buf[:n])
+
+			data := buf[:n]
+			for {
+				i := bytes.IndexByte(data, '\n')
+				if i < 0 {
+					break
+				}
+				partial = append(partial, data[:i]...)
+				p.emit(LogLine{Time: time.Now(), Stream: StreamStdout, Text: cleanTTYLine(partial)})
+				partial = partial[:0]
+				data = data[i+1:]
+			}
+			partial = append(partial, data...)
+			if len(partial) >= maxLineLength {
+				p.emit(LogLine{Time: time.Now(), Stream: StreamStdout, Text: cleanTTYLine(partial)})
+				partial = partial[:0]
+			}
+		}
+		if err != nil {
+			// Linux reports EIO once the last slave descriptor is closed
+			if len(partial) > 0 {
+				p.emit(LogLine{Time: time.Now(), Stream: StreamStdout, Text: cleanTTYLine(partial)})
+			}
+			p.flushDropped()
+			return
+		}
+	}
+}
+
+// cleanTTYLine turns a raw terminal line into log text: carriage-return
+// redraws keep only their final state and escape sequences other than
+// colors are removed.
+func cleanTTYLine(raw []byte) string {
+	line := strings.TrimSuffix(string(raw), "\r")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c != 0x1b {
+			if c >= 0x20 || c == '\t' {
+				b.WriteByte(c)
+			}
+			continue
+		}
+		if i+1 >= len(line) {
+			break
+		}
+		switch line[i+1] {
+		case '[':
+			// CSI: parameters up to a final byte in 0x40-0x7E
+			j := i + 2
+			for j < len(line) && (line[j] < 0x40 || line[j] > 0x7e) {
+				j++
+			}
+			if j < len(line) && line[j] == 'm' {
+				b.WriteString(line[i : j+1])
+			}
+			i = j
+		case ']':
+			// OSC: up to BEL or ESC \
+			j := i + 2
+			for j < len(line) && line[j] != 0x07 && !(line[j] == 0x1b && j+1 < len(line) && line[j+1] == '\\') {
+				j++
+			}
+			if j < len(line) && line[j] == 0x1b {
+				j++
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+// fillOutput emits lines until Output is full, as if the UI stopped reading.
+func fillOutput(p *Process) {
+	for i := 0; i < cap(p.Output); i++ {
+		p.emit(LogLine{Stream: StreamStdout, Text: fmt.Sprintf("line %d", i)})
+	}
+}
+
+func TestCloseReleasesFlushDropped(t *testing.T) {
+	p := NewProcess(config.Task{Name: "app", LogOverflow: config.OverflowDropNewest})
+	fillOutput(p)
+	p.emit(LogLine{Stream: StreamStdout, Text: "dropped"})
+
+	done := make(chan struct{})
+	go func() {
+		p.flushDropped()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("flushDropped returned with a full channel")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	p.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("flushDropped still blocked after Close")
+	}
+}
+
+func TestCloseRemovesSpill(t *testing.T) {
+	p := NewProcess(config.Task{Name: "app", LogOverflow: config.OverflowSpill})
+	fillOutput(p)
+	for i := 0; i < 100; i++ {
+		p.emit(LogLine{Stream: StreamStdout, Text: "spilled"})
+	}
+
+	p.pipeMu.Lock()
+	if p.spill == nil {
+		p.pipeMu.Unlock()
+		t.Fatal("no spill file with a full channel")
+	}
+	name := p.spill.file.Name()
+	p.pipeMu.Unlock()
+
+	p.Close()
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("spill file %s still exists: %v", name, err)
+	}
+
+	// Nothing reads Output anymore, later lines are dropped
+	p.emit(LogLine{Stream: StreamStdout, Text: "after close"})
+	p.pipeMu.Lock()
+	defer p.pipeMu.Unlock()
+	if p.spill != nil {
+		t.Error("spilling again after Close")
+	}
+}
@@ -1,14 +1,15 @@
 package process
 
 import (
-	"bufio"
+	"errors"
+	"fmt"
 	"io"
-	"net"
-	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"strings"
-	"sync/atomic"
+	"sync"
 	"time"
 
 	"github.com/kuo-hm/devdeck/config"
@@ -16,208 +17,543 @@ import (
 )
 
 // Process represents a running task with its configuration and state.
+// All mutable state is guarded by mu and exposed through methods; lifecycle
+// changes are published on Events.
 type Process struct {
-	Config    config.Task
-	Cmd       *exec.Cmd
-	Status    string
-	Output    chan string
-	Err       error
-	LogBuffer string
-	Stdin     io.WriteCloser
-
-	CPUUsage float64
-	MemUsage uint64
+	Config config.Task
+	Output chan LogLine
+	Events chan Event
+	Logs   *LogBuffer
+	Screen *Terminal // Emulated screen, only for tasks with tty: true
+
+	mu            sync.Mutex
+	state         State
+	err           error
+	changed       chan struct{} // Closed and replaced on every transition
+	cmd           *exec.Cmd
+	stdin         io.WriteCloser
+	pty           *os.File      // Master side of the terminal, tty tasks only
+	cols, rows    int           // Terminal size requested by the UI
+	done          chan struct{} // Closed when the current run exits
+	startedAt     time.Time
+	stoppedByUser bool          // Suppresses automatic restarts
+	cancelBackoff chan struct{} // Closed to abort a pending automatic restart
+	cancelWait    chan struct{} // Closed to abort a pending StartAfter
+	blockedOn     string        // Dependency the task is waiting for
+	restarts      int           // Automatic restarts since the counter was last reset
+	nextRestart   time.Time     // When the pending automatic restart fires
+	history       []Run         // Last historySize finished runs
+	healthDetail  string        // Result of the last health probe
+	probes        []Probe       // Last probeHistorySize probe results
+	healthFlips   []time.Time   // Healthy <-> Unhealthy changes, for flap detection
+
+	unhealthySince    time.Time // When the task last became unhealthy
+	unhealthyStreak   int       // on_unhealthy restarts since the task was last healthy
+	unhealthyRestarts int       // on_unhealthy restarts in total
+	unhealthyGaveUp   bool      // max_unhealthy_restarts reached and reported
+
+	healthPattern    *regexp.Regexp // Log health check: output line marking the task healthy
+	unhealthyPattern *regexp.Regexp // Log health check: output line marking it unhealthy
+	bodyPattern      *regexp.Regexp // HTTP health check: body_regex
+
+	cpuUsage float64
+	memUsage uint64
 	gopsProc *ps.Process
 
-	HealthStatus string // "Unchecked", "Healthy", "Unhealthy", "Starting"
-
-	epoch int32 // For handling restart races
+	pipeMu  sync.Mutex    // Guards the overflow state of the output pipeline
+	dropped int           // Lines dropped since the last marker
+	spill   *spillFile    // Non-nil while lines are buffered on disk
+	closed  chan struct{} // Closed by Close, nothing reads Output anymore
 }
 
+// DefaultStopTimeout is how long Stop waits after the stop signal before killing.
+const DefaultStopTimeout = 5000 * time.Millisecond
+
+// KillWait bounds how long Kill waits for killed processes to disappear.
+const KillWait = 2 * time.Second
+
+// ErrAlreadyRunning is returned by Start when the process is still alive.
+var ErrAlreadyRunning = errors.New("process is already running")
+
 // NewProcess creates a new Process instance from a task configuration.
 func NewProcess(cfg config.Task) *Process {
-	return &Process{
-		Config:       cfg,
-		Status:       "Stopped",
-		HealthStatus: "Unchecked",
-		Output:       make(chan string, 1000),
+	p := &Process{
+		Config:  cfg,
+		Output:  make(chan LogLine, 1000),
+		Events:  make(chan Event, 100),
+		Logs:    NewLogBuffer(cfg.MaxLogLines),
+		state:   StatePending,
+		changed: make(chan struct{}),
+		closed:  make(chan struct{}),
+		cols:    defaultCols,
+		rows:    defaultRows,
+	}
+	if cfg.TTY {
+		p.Screen = NewTerminal(p.cols, p.rows, writerFunc(p.writePTY))
 	}
+	p.compileHealthPatterns()
+	return p
 }
 
 // Start executes the process command and begins streaming output.
 func (p *Process) Start() error {
-	p.Err = nil
-	parts := strings.Fields(p.Config.Command)
-	if len(parts) == 0 {
+	p.mu.Lock()
+	p.stoppedByUser = false
+	p.abortBackoffLocked()
+	p.abortWaitLocked()
+	p.mu.Unlock()
+	return p.start()
+}
+
+func (p *Process) start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.startLocked()
+}
+
+// startLocked launches a new run. The caller must hold p.mu.
+func (p *Process) startLocked() error {
+	if p.aliveLocked() {
+		return ErrAlreadyRunning
+	}
+
+	argv := p.Argv()
+	if len(argv) == 0 {
 		return nil
 	}
+	if err := p.setStateLocked(StateStarting, nil); err != nil {
+		return err
+	}
+
+	c := p.command(argv)
+
+	var err error
+	if p.Config.TTY {
+		err = p.startTTYLocked(c)
+	} else {
+		err = p.startPipesLocked(c)
+	}
+	if err != nil {
+		_ = p.setStateLocked(StateFailed, err)
+		return err
+	}
+
+	p.cmd = c
+	p.startedAt = time.Now()
+	startedAt := p.startedAt
+	done := make(chan struct{})
+	p.done = done
+
+	// Create resource monitor handle
+	p.gopsProc, _ = ps.NewProcess(int32(c.Process.Pid))
+
+	// Tasks with a health check stay Starting until the first probe, or the
+	// first matching line for log checks
+	if hc := p.Config.HealthCheck; hc != nil {
+		if hc.Type != config.HealthLog {
+			go p.monitorHealth(done)
+		}
+	} else {
+		_ = p.setStateLocked(StateRunning, nil)
+	}
+
+	go func() {
+		err := c.Wait()
+
+		p.mu.Lock()
+		stopping := p.state == StateStopping
+
+		// Don't leave orphaned children holding ports after the leader exits.
+		// During Stop the remaining tree gets its grace period instead.
+		if !stopping {
+			killOrphans(c.Process.Pid)
+		}
+
+		p.recordRunLocked(c.ProcessState, stopping, err)
+		if err != nil && !stopping {
+			_ = p.setStateLocked(StateFailed, err)
+		} else {
+			// Exits caused by Stop are not errors
+			_ = p.setStateLocked(StateExited, nil)
+		}
+		// Decide on the restart before dependents can see the failure
+		var note string
+		if !stopping && p.shouldRestartLocked(err) {
+			note = p.scheduleRestartLocked(time.Since(startedAt))
+		}
+		close(done)
+		p.mu.Unlock()
+
+		if note != "" {
+			p.notice("%s", note)
+		}
+	}()
 
-	c := exec.Command(parts[0], parts[1:]...)
+	return nil
+}
+
+// command prepares argv to run in the task's directory and environment.
+func (p *Process) command(argv []string) *exec.Cmd {
+	c := exec.Command(argv[0], argv[1:]...)
 	if p.Config.Directory != "" {
 		c.Dir = p.Config.Directory
 	}
 	c.Env = os.Environ()
 	c.Env = append(c.Env, p.Config.Env...)
+	return c
+}
+
+// startPipesLocked starts c with stdin, stdout and stderr connected to pipes.
+// The caller must hold p.mu.
+func (p *Process) startPipesLocked(c *exec.Cmd) error {
+	setProcessGroup(c)
 
 	stdin, err := c.StdinPipe()
 	if err != nil {
-		p.Status = "Error"
-		p.Err = err
 		return err
 	}
-	p.Stdin = stdin
 
-	stdout, err := c.StdoutPipe()
+	// Use our own pipes so that Wait doesn't close them under the readers;
+	// they are drained until every writer, including grandchildren, is gone.
+	stdout, stdoutW, err := os.Pipe()
 	if err != nil {
-		p.Status = "Error"
-		p.Err = err
 		return err
 	}
-
-	stderr, err := c.StderrPipe()
+	stderr, stderrW, err := os.Pipe()
 	if err != nil {
-		p.Status = "Error"
-		p.Err = err
+		stdout.Close()
+		stdoutW.Close()
 		return err
 	}
+	c.Stdout = stdoutW
+	c.Stderr = stderrW
 
-	if err := c.Start(); err != nil {
-		p.Status = "Error"
-		p.Err = err
+	err = c.Start()
+	// The child has its own copies of the write ends now
+	stdoutW.Close()
+	stderrW.Close()
+	if err != nil {
+		stdout.Close()
+		stderr.Close()
 		return err
 	}
 
-	p.Cmd = c
-	p.Status = "Running"
+	p.stdin = stdin
+	p.pty = nil
+	go p.readLines(stdout, StreamStdout)
+	go p.readLines(stderr, StreamStderr)
+	return nil
+}
 
-	// Create resource monitor handle
-	if c.Process != nil {
-		p.gopsProc, _ = ps.NewProcess(int32(c.Process.Pid))
+// startTTYLocked starts c attached to a new pseudo-terminal.
+// The caller must hold p.mu.
+func (p *Process) startTTYLocked(c *exec.Cmd) error {
+	c.Env = append(c.Env, "TERM=xterm-256color")
+	master, err := startPTY(c, p.cols, p.rows)
+	if err != nil {
+		return err
 	}
 
-	// Start Health Check Loop
-	if p.Config.HealthCheck != nil {
-		go p.monitorHealth()
+	p.stdin = master
+	p.pty = master
+	go p.readTerminal(master, p.Screen)
+	return nil
+}
+
+// Resize sets the terminal size of a tty task. It is a no-op for other tasks.
+func (p *Process) Resize(cols, rows int) {
+	if p.Screen == nil || cols <= 0 || rows <= 0 {
+		return
 	}
+	p.mu.Lock()
+	if cols == p.cols && rows == p.rows {
+		p.mu.Unlock()
+		return
+	}
+	p.cols, p.rows = cols, rows
+	master := p.pty
+	alive := p.aliveLocked()
+	p.mu.Unlock()
+
+	// Outside p.mu: the screen calls writePTY while holding its own lock
+	p.Screen.Resize(cols, rows)
+	if master != nil && alive {
+		_ = resizePTY(master, cols, rows)
+	}
+}
 
-	consume := func(r *bufio.Scanner) {
-		for r.Scan() {
-			p.Output <- r.Text()
-		}
+// writePTY writes to the terminal of the current run, if any.
+func (p *Process) writePTY(data []byte) (int, error) {
+	p.mu.Lock()
+	master := p.pty
+	p.mu.Unlock()
+	if master == nil {
+		return len(data), nil
 	}
+	return master.Write(data)
+}
 
-	go consume(bufio.NewScanner(stdout))
-	go consume(bufio.NewScanner(stderr))
+// writerFunc adapts a function to io.Writer.
+type writerFunc func([]byte) (int, error)
 
-	// Atomic Epoch (Wait Logic)
-	currentEpoch := atomic.AddInt32(&p.epoch, 1)
+func (f writerFunc) Write(data []byte) (int, error) {
+	return f(data)
+}
 
-	go func() {
-		err := c.Wait()
+// CommandMode describes how the task command is executed: "args", "shell" or "split".
+func (p *Process) CommandMode() string {
+	if len(p.Config.Args) > 0 {
+		return "args"
+	}
+	if p.Config.Shell != "" && p.Config.Shell != config.ShellNone {
+		return "shell"
+	}
+	return "split"
+}
 
-		// Only update status if we are still in the same epoch
-		if atomic.LoadInt32(&p.epoch) == currentEpoch {
-			if err != nil {
-				p.Status = "Error"
-				p.Err = err
-			} else {
-				p.Status = "Stopped"
-			}
+// Argv returns the argument vector used to launch the task.
+func (p *Process) Argv() []string {
+	if p.CommandMode() == "args" {
+		return p.Config.Args
+	}
+	return p.commandArgv(p.Config.Command)
+}
+
+// commandArgv returns the argument vector for a command string run the way
+// the task's command is: through its shell if it has one, split otherwise.
+func (p *Process) commandArgv(command string) []string {
+	if p.Config.Shell != "" && p.Config.Shell != config.ShellNone {
+		if strings.TrimSpace(command) == "" {
+			return nil
 		}
-	}()
+		return []string{p.Config.Shell, shellFlag(p.Config.Shell), command}
+	}
+	// Legacy behavior: whitespace splitting, no quoting or expansion
+	return strings.Fields(command)
+}
 
-	return nil
+// shellFlag returns the flag an interpreter expects before an inline script.
+func shellFlag(shell string) string {
+	name := strings.ToLower(filepath.Base(shell))
+	name = strings.TrimSuffix(name, ".exe")
+	switch name {
+	case "cmd":
+		return "/C"
+	case "powershell", "pwsh":
+		return "-Command"
+	default:
+		return "-c"
+	}
 }
 
-// Stop terminates the running process.
+// Stop sends the configured stop signal to the task's process group, waits up
+// to stop_timeout for the whole process tree to exit and kills whatever is
+// still alive. It blocks until the tree is gone.
 func (p *Process) Stop() error {
-	if p.Cmd != nil && p.Cmd.Process != nil {
-		return p.Cmd.Process.Kill()
-	}
-	return nil
+	return p.stop(true)
 }
 
-// Restart stops and then starts the process.
-func (p *Process) Restart() error {
-	if p.Status == "Running" {
-		_ = p.Stop()
+// stop implements Stop. Unless byUser, automatic restarts stay enabled.
+func (p *Process) stop(byUser bool) error {
+	p.mu.Lock()
+	if byUser {
+		p.stoppedByUser = true
 	}
-	return p.Start()
+	if p.abortBackoffLocked() || p.abortWaitLocked() {
+		_ = p.setStateLocked(StateExited, nil)
+	}
+	if !p.aliveLocked() || p.state == StateStopping {
+		// Not running, or another Stop is already in progress
+		done := p.done
+		p.mu.Unlock()
+		if done != nil {
+			<-done
+		}
+		return nil
+	}
+	_ = p.setStateLocked(StateStopping, nil)
+	c, done := p.cmd, p.done
+	p.mu.Unlock()
+
+	sig, ok := parseSignal(p.Config.StopSignal)
+	if !ok {
+		p.notice("unknown stop_signal %q, using default", p.Config.StopSignal)
+	}
+
+	timeout := time.Duration(p.Config.StopTimeout) * time.Millisecond
+	if timeout == 0 {
+		timeout = DefaultStopTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	// Snapshot the tree first; once the leader exits its children are reparented
+	pid := c.Process.Pid
+	tree := descendants(int32(pid))
+
+	if err := signalGroup(c.Process, sig); err != nil {
+		// Signal could not be delivered, fall back to killing
+		return p.killTree(c, done, tree)
+	}
+	signalEscaped(tree, pid, sig)
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		p.notice("still running after %s, killing", timeout)
+		return p.killTree(c, done, tree)
+	}
+
+	// The leader is gone, give the rest of the tree what is left of the timeout
+	if !waitPids(tree, deadline) {
+		p.notice("child processes still running after %s, killing", timeout)
+		return p.killTree(c, done, tree)
+	}
+	return nil
 }
 
-// SendInput writes the input string to the process stdin.
-func (p *Process) SendInput(input string) error {
-	if p.Status != "Running" || p.Stdin == nil {
+// Kill terminates the process tree immediately and waits for it to exit.
+func (p *Process) Kill() error {
+	p.mu.Lock()
+	if !p.aliveLocked() {
+		p.mu.Unlock()
 		return nil
 	}
-	_, err := io.WriteString(p.Stdin, input+"\n")
+	if p.state != StateStopping {
+		_ = p.setStateLocked(StateStopping, nil)
+	}
+	c, done := p.cmd, p.done
+	p.mu.Unlock()
+
+	return p.killTree(c, done, descendants(int32(c.Process.Pid)))
+}
+
+// killTree sends SIGKILL to the process group of c and to every PID in tree,
+// then waits for all of them to exit.
+func (p *Process) killTree(c *exec.Cmd, done chan struct{}, tree []int32) error {
+	err := signalGroup(c.Process, os.Kill)
+	<-done
+	signalEscaped(tree, c.Process.Pid, os.Kill)
+	waitPids(tree, time.Now().Add(KillWait))
 	return err
 }
 
-// UpdateStats fetches current resource usage for the process.
-func (p *Process) UpdateStats() {
-	if p.Status != "Running" || p.gopsProc == nil {
-		p.CPUUsage = 0
-		p.MemUsage = 0
-		return
+// aliveLocked reports whether the current run has not exited yet.
+// The caller must hold p.mu.
+func (p *Process) aliveLocked() bool {
+	if p.done == nil {
+		return false
 	}
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
 
-	cpuPercent, err := p.gopsProc.Percent(0)
-	if err == nil {
-		p.CPUUsage = cpuPercent
+// Restart stops and then starts the process. A manual restart also clears
+// the automatic restart counter.
+func (p *Process) Restart() error {
+	_ = p.Stop()
+	p.mu.Lock()
+	p.restarts = 0
+	p.mu.Unlock()
+	return p.Start()
+}
+
+// restartUnhealthy restarts the task for on_unhealthy. Unlike Restart it
+// keeps the restart counter, and it leaves the task alone if the user stopped
+// or started it in the meantime.
+func (p *Process) restartUnhealthy() {
+	p.mu.Lock()
+	c := p.cmd
+	p.mu.Unlock()
+
+	_ = p.stop(false)
+
+	p.mu.Lock()
+	// Stopped, started or about to start otherwise in the meantime
+	if p.stoppedByUser || p.cmd != c || p.aliveLocked() || p.cancelWait != nil || p.cancelBackoff != nil {
+		p.mu.Unlock()
+		return
+	}
+	err := p.startLocked()
+	p.mu.Unlock()
+	if err != nil {
+		p.notice("restart failed: %v", err)
 	}
+}
 
-	memInfo, err := p.gopsProc.MemoryInfo()
-	if err == nil {
-		p.MemUsage = memInfo.RSS // Resident Set Size in bytes
+// SendInput writes the input string to the process stdin, followed by Enter.
+func (p *Process) SendInput(input string) error {
+	if p.Config.TTY {
+		// Enter on a terminal sends a carriage return
+		return p.WriteInput([]byte(input + "\r"))
 	}
+	return p.WriteInput([]byte(input + "\n"))
 }
 
-// monitorHealth runs periodically to check service status
-func (p *Process) monitorHealth() {
-	hc := p.Config.HealthCheck
-	interval := time.Duration(hc.Interval) * time.Millisecond
-	if interval == 0 {
-		interval = 2000 * time.Millisecond
-	} // Default 2s
+// WriteInput writes raw bytes to the process stdin, or to its terminal for
+// tty tasks. Input for a process that is not running is discarded.
+func (p *Process) WriteInput(data []byte) error {
+	p.mu.Lock()
+	stdin := p.stdin
+	alive := p.aliveLocked() && p.state != StateStopping
+	p.mu.Unlock()
 
-	for p.Status == "Running" {
-		if p.checkHealth() {
-			p.HealthStatus = "Healthy"
-		} else {
-			p.HealthStatus = "Unhealthy"
-		}
-		time.Sleep(interval)
+	if !alive || stdin == nil {
+		return nil
 	}
-	p.HealthStatus = "Unchecked"
+	_, err := stdin.Write(data)
+	return err
 }
 
-func (p *Process) checkHealth() bool {
-	hc := p.Config.HealthCheck
-	if hc == nil {
-		return true
+// Signal sends the named signal, e.g. "SIGINT", to the task's process group.
+// Signals for a process that is not running are discarded.
+func (p *Process) Signal(name string) error {
+	sig, ok := lookupSignal(name)
+	if !ok {
+		return fmt.Errorf("can't send %s on this platform", name)
 	}
 
-	timeout := time.Duration(hc.Timeout) * time.Millisecond
-	if timeout == 0 {
-		timeout = 1000 * time.Millisecond
+	p.mu.Lock()
+	c := p.cmd
+	alive := p.aliveLocked() && p.state != StateStopping
+	p.mu.Unlock()
+
+	if !alive {
+		return nil
 	}
+	return signalGroup(c.Process, sig)
+}
 
-	if hc.Type == "tcp" {
-		conn, err := net.DialTimeout("tcp", hc.Target, timeout)
-		if err != nil {
-			return false
+// UpdateStats fetches current resource usage for the process.
+func (p *Process) UpdateStats() {
+	p.mu.Lock()
+	proc := p.gopsProc
+	alive := p.aliveLocked()
+	p.mu.Unlock()
+
+	var cpuUsage float64
+	var memUsage uint64
+	if alive && proc != nil {
+		if cpuPercent, err := proc.Percent(0); err == nil {
+			cpuUsage = cpuPercent
 		}
-		conn.Close()
-		return true
-	} else if hc.Type == "http" {
-		client := http.Client{Timeout: timeout}
-		resp, err := client.Get(hc.Target)
-		if err != nil {
-			return false
+		if memInfo, err := proc.MemoryInfo(); err == nil {
+			memUsage = memInfo.RSS // Resident Set Size in bytes
 		}
-		defer resp.Body.Close()
-		return resp.StatusCode >= 200 && resp.StatusCode < 400
 	}
-	return false
+
+	p.mu.Lock()
+	p.cpuUsage = cpuUsage
+	p.memUsage = memUsage
+	p.mu.Unlock()
+}
+
+// Stats returns the last sampled CPU percentage and resident memory in bytes.
+func (p *Process) Stats() (float64, uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cpuUsage, p.memUsage
 }
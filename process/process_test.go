@@ -0,0 +1,148 @@
+//go:build !windows
+
+package process
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+// startTree starts a task and waits until its process tree is complete.
+func startTree(t *testing.T, task config.Task, want int) (*Process, []int32) {
+	t.Helper()
+	p := NewProcess(task)
+	if err := p.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	pid := int32(p.cmd.Process.Pid)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		tree := descendants(pid)
+		if len(tree) >= want {
+			return p, tree
+		}
+		if time.Now().After(deadline) {
+			_ = p.Kill()
+			t.Fatalf("got %d descendants, want %d", len(tree), want)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestStopKillsGrandchildren(t *testing.T) {
+	p, tree := startTree(t, config.Task{
+		Name: "tree",
+		Args: []string{"sh", "-c", "sleep 300 & sleep 300 & wait"},
+	}, 2)
+
+	if err := p.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if alive := alivePids(tree); len(alive) != 0 {
+		t.Errorf("processes still alive after Stop: %v", alive)
+	}
+	if state := p.State(); state != StateExited {
+		t.Errorf("state = %s, want %s", state, StateExited)
+	}
+}
+
+func TestStopEscalatesToKill(t *testing.T) {
+	p, tree := startTree(t, config.Task{
+		Name:        "stubborn",
+		Args:        []string{"sh", "-c", "trap '' TERM; sleep 300 & wait"},
+		StopTimeout: 300,
+	}, 1)
+
+	start := time.Now()
+	if err := p.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("Stop returned after %s, before stop_timeout", elapsed)
+	}
+	if alive := alivePids(tree); len(alive) != 0 {
+		t.Errorf("processes still alive after Stop: %v", alive)
+	}
+
+	run, ok := p.LastRun()
+	if !ok {
+		t.Fatal("no run recorded")
+	}
+	if run.Signal != "SIGKILL" {
+		t.Errorf("run ended by %q, want SIGKILL", run.Signal)
+	}
+}
+
+func TestRestartUnhealthy(t *testing.T) {
+	p, _ := startTree(t, config.Task{Name: "api", Args: []string{"sh", "-c", "sleep 300 & wait"}}, 1)
+	defer p.Kill()
+	p.mu.Lock()
+	p.restarts = 3
+	pid := p.cmd.Process.Pid
+	p.mu.Unlock()
+
+	p.restartUnhealthy()
+	if state := p.State(); state != StateRunning {
+		t.Fatalf("state = %s, want %s", state, StateRunning)
+	}
+	p.mu.Lock()
+	restarted := p.cmd.Process.Pid != pid
+	p.mu.Unlock()
+	if !restarted {
+		t.Error("still the same run")
+	}
+	if n := p.Restarts(); n != 3 {
+		t.Errorf("restarts = %d, want 3", n)
+	}
+}
+
+func TestRestartUnhealthyStoppedByUser(t *testing.T) {
+	p, _ := startTree(t, config.Task{
+		Name:        "api",
+		Args:        []string{"sh", "-c", "trap '' TERM; sleep 300 & wait"},
+		StopTimeout: 300,
+	}, 1)
+	defer p.Kill()
+
+	done := make(chan struct{})
+	go func() {
+		p.restartUnhealthy()
+		close(done)
+	}()
+	for p.State() != StateStopping {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := p.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	<-done
+
+	if state := p.State(); state != StateExited {
+		t.Errorf("state = %s, want %s", state, StateExited)
+	}
+}
+
+func TestSignal(t *testing.T) {
+	p, tree := startTree(t, config.Task{Name: "repl", Args: []string{"sh", "-c", "sleep 300 & wait"}}, 1)
+	defer p.Kill()
+
+	if err := p.Signal("SIGINT"); err != nil {
+		t.Fatalf("Signal: %v", err)
+	}
+	<-p.done
+	if run, _ := p.LastRun(); run.Signal != "SIGINT" {
+		t.Errorf("run ended by %q, want SIGINT", run.Signal)
+	}
+	// The whole group got the signal
+	waitPids(tree, time.Now().Add(time.Second))
+	if alive := alivePids(tree); len(alive) != 0 {
+		t.Errorf("processes still alive after SIGINT: %v", alive)
+	}
+
+	if err := p.Signal("SIGWINCH"); err == nil {
+		t.Error("Signal accepted an unknown signal")
+	}
+}
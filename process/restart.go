@@ -0,0 +1,146 @@
+package process
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+// Restart policy defaults
+const (
+	DefaultRestartBackoff    = 1000 * time.Millisecond
+	DefaultRestartMaxBackoff = 30000 * time.Millisecond
+	DefaultRestartReset      = 60000 * time.Millisecond
+)
+
+// shouldRestartLocked decides whether an exit should trigger an automatic
+// restart. The caller must hold p.mu.
+func (p *Process) shouldRestartLocked(exitErr error) bool {
+	if p.stoppedByUser {
+		return false
+	}
+	switch p.Config.Restart {
+	case config.RestartOnFailure:
+		return exitErr != nil
+	case config.RestartAlways, config.RestartUnlessStopped:
+		return true
+	}
+	return false
+}
+
+// StoppedByUser reports whether the last stop was requested by the user.
+func (p *Process) StoppedByUser() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stoppedByUser
+}
+
+// Restarts returns the number of automatic restarts since the counter was last reset.
+func (p *Process) Restarts() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.restarts
+}
+
+// NextRestart returns when the pending automatic restart fires, or the zero
+// time if none is pending.
+func (p *Process) NextRestart() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.nextRestart
+}
+
+// scheduleRestartLocked puts the process into Backoff and starts it again
+// once the backoff delay has passed, unless Stop or Start is called first.
+// Dependents never see the failed run without the pending restart. It returns
+// a note for the log, which the caller emits after releasing p.mu. The caller
+// must hold p.mu.
+func (p *Process) scheduleRestartLocked(uptime time.Duration) string {
+	// A run that stayed up long enough counts as healthy again
+	if uptime >= millis(p.Config.RestartReset, DefaultRestartReset) {
+		p.restarts = 0
+	}
+
+	restarts := p.restarts
+	if max := p.Config.MaxRestarts; max > 0 && restarts >= max {
+		return fmt.Sprintf("giving up after %d restarts", restarts)
+	}
+
+	if err := p.setStateLocked(StateBackoff, nil); err != nil {
+		// Stopped or started by the user in the meantime
+		return ""
+	}
+	delay := p.backoffDelayLocked()
+	cancel := make(chan struct{})
+	p.cancelBackoff = cancel
+	p.nextRestart = time.Now().Add(delay)
+
+	go func() {
+		select {
+		case <-cancel:
+			return
+		case <-time.After(delay):
+		}
+
+		p.mu.Lock()
+		select {
+		case <-cancel:
+			// Cancelled while the timer fired
+			p.mu.Unlock()
+			return
+		default:
+		}
+		p.restarts++
+		p.cancelBackoff = nil
+		p.nextRestart = time.Time{}
+		err := p.startLocked()
+		var note string
+		if err != nil && p.shouldRestartLocked(err) {
+			note = p.scheduleRestartLocked(0)
+		}
+		p.mu.Unlock()
+
+		if err != nil {
+			p.notice("restart failed: %v", err)
+		}
+		if note != "" {
+			p.notice("%s", note)
+		}
+	}()
+	return fmt.Sprintf("exited, restarting in %s (attempt %d)", delay, restarts+1)
+}
+
+// backoffDelayLocked doubles the initial delay for every restart, up to the
+// maximum. The caller must hold p.mu.
+func (p *Process) backoffDelayLocked() time.Duration {
+	delay := millis(p.Config.RestartBackoff, DefaultRestartBackoff)
+	limit := millis(p.Config.RestartMaxBackoff, DefaultRestartMaxBackoff)
+	for i := 0; i < p.restarts && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// abortBackoffLocked cancels a pending automatic restart and reports whether
+// one was pending. The caller must hold p.mu.
+func (p *Process) abortBackoffLocked() bool {
+	if p.cancelBackoff == nil {
+		return false
+	}
+	close(p.cancelBackoff)
+	p.cancelBackoff = nil
+	p.nextRestart = time.Time{}
+	return true
+}
+
+// millis converts a millisecond config value, falling back to def when unset.
+func millis(ms int, def time.Duration) time.Duration {
+	if ms <= 0 {
+		return def
+	}
+	return time.Duration(ms) * time.Millisecond
+}
@@ -0,0 +1,131 @@
+package process
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		backoff, maxBackoff int
+		restarts            int
+		want                time.Duration
+	}{
+		{0, 0, 0, DefaultRestartBackoff},
+		{0, 0, 1, 2 * time.Second},
+		{0, 0, 4, 16 * time.Second},
+		{0, 0, 5, DefaultRestartMaxBackoff},
+		{0, 0, 100, DefaultRestartMaxBackoff},
+		{100, 1000, 0, 100 * time.Millisecond},
+		{100, 1000, 3, 800 * time.Millisecond},
+		{100, 1000, 4, time.Second},
+		{2000, 1000, 0, time.Second},
+	}
+	for _, tt := range tests {
+		p := NewProcess(config.Task{Name: "app", RestartBackoff: tt.backoff, RestartMaxBackoff: tt.maxBackoff})
+		p.restarts = tt.restarts
+		if got := p.backoffDelayLocked(); got != tt.want {
+			t.Errorf("backoff %d, max %d, %d restarts: delay = %s, want %s", tt.backoff, tt.maxBackoff, tt.restarts, got, tt.want)
+		}
+	}
+}
+
+func TestShouldRestart(t *testing.T) {
+	failed := errors.New("exit status 1")
+	tests := []struct {
+		policy        string
+		exitErr       error
+		stoppedByUser bool
+		want          bool
+	}{
+		{"", failed, false, false},
+		{config.RestartNo, failed, false, false},
+		{config.RestartOnFailure, failed, false, true},
+		{config.RestartOnFailure, nil, false, false},
+		{config.RestartAlways, nil, false, true},
+		{config.RestartAlways, failed, false, true},
+		// unless-stopped only differs from always on config reload
+		{config.RestartUnlessStopped, nil, false, true},
+		{config.RestartOnFailure, failed, true, false},
+		{config.RestartAlways, nil, true, false},
+		{config.RestartUnlessStopped, failed, true, false},
+	}
+	for _, tt := range tests {
+		p := NewProcess(config.Task{Name: "app", Restart: tt.policy})
+		p.stoppedByUser = tt.stoppedByUser
+		if got := p.shouldRestartLocked(tt.exitErr); got != tt.want {
+			t.Errorf("restart %q, exit error %v, stopped by user %v: got %v, want %v", tt.policy, tt.exitErr, tt.stoppedByUser, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleRestart(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxRestarts  int
+		restartReset int
+		restarts     int
+		uptime       time.Duration
+		wantState    State
+		wantRestarts int
+		wantNote     string
+	}{
+		{"first restart", 0, 0, 0, 0, StateBackoff, 0, "exited, restarting in 1s (attempt 1)"},
+		{"unlimited", 0, 0, 20, 0, StateBackoff, 20, "exited, restarting in 30s (attempt 21)"},
+		{"below max", 3, 0, 2, 0, StateBackoff, 2, "exited, restarting in 4s (attempt 3)"},
+		{"max reached", 3, 0, 3, 0, StateFailed, 3, "giving up after 3 restarts"},
+		{"short run keeps count", 3, 500, 3, 499 * time.Millisecond, StateFailed, 3, "giving up after 3 restarts"},
+		{"long run resets count", 3, 500, 3, 500 * time.Millisecond, StateBackoff, 0, "exited, restarting in 1s (attempt 1)"},
+		{"default reset", 0, 0, 5, DefaultRestartReset, StateBackoff, 0, "exited, restarting in 1s (attempt 1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProcess(config.Task{Name: "app", MaxRestarts: tt.maxRestarts, RestartReset: tt.restartReset})
+			p.state = StateFailed
+			p.restarts = tt.restarts
+
+			p.mu.Lock()
+			note := p.scheduleRestartLocked(tt.uptime)
+			state, restarts := p.state, p.restarts
+			p.abortBackoffLocked()
+			p.mu.Unlock()
+
+			if note != tt.wantNote {
+				t.Errorf("note = %q, want %q", note, tt.wantNote)
+			}
+			if state != tt.wantState {
+				t.Errorf("state = %s, want %s", state, tt.wantState)
+			}
+			if restarts != tt.wantRestarts {
+				t.Errorf("restarts = %d, want %d", restarts, tt.wantRestarts)
+			}
+		})
+	}
+}
+
+func TestStopSuppressesRestart(t *testing.T) {
+	p := NewProcess(config.Task{Name: "app", Restart: config.RestartAlways})
+	p.state = StateFailed
+
+	p.mu.Lock()
+	p.scheduleRestartLocked(0)
+	p.mu.Unlock()
+	if err := p.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	if state := p.State(); state != StateExited {
+		t.Errorf("state after Stop = %s, want %s", state, StateExited)
+	}
+	if next := p.NextRestart(); !next.IsZero() {
+		t.Errorf("restart still pending at %s", next)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.shouldRestartLocked(nil) {
+		t.Error("restart after Stop")
+	}
+}
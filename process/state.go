@@ -0,0 +1,207 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+// State is a step in the lifecycle of a process.
+type State int
+
+const (
+	StatePending          State = iota // Never started
+	StateStarting                      // Launching, or waiting for the first health check
+	StateRunning                       // Up, no health check configured
+	StateHealthy                       // Up and passing its health check
+	StateUnhealthy                     // Up but failing its health check
+	StateStopping                      // Stop signal sent, waiting for exit
+	StateExited                        // Exited cleanly or was stopped
+	StateFailed                        // Exited with an error or could not be started
+	StateBackoff                       // Waiting for an automatic restart
+	StateBlocked                       // Waiting for its dependencies
+	StateDependencyFailed              // Not started because a dependency failed or timed out
+)
+
+var stateNames = map[State]string{
+	StatePending:          "Pending",
+	StateStarting:         "Starting",
+	StateRunning:          "Running",
+	StateHealthy:          "Healthy",
+	StateUnhealthy:        "Unhealthy",
+	StateStopping:         "Stopping",
+	StateExited:           "Exited",
+	StateFailed:           "Failed",
+	StateBackoff:          "Backoff",
+	StateBlocked:          "Blocked",
+	StateDependencyFailed: "DependencyFailed",
+}
+
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Alive reports whether a process in this state has a live OS process.
+func (s State) Alive() bool {
+	switch s {
+	case StateStarting, StateRunning, StateHealthy, StateUnhealthy, StateStopping:
+		return true
+	}
+	return false
+}
+
+// transitions lists the valid target states for every state.
+var transitions = map[State][]State{
+	StatePending:          {StateStarting, StateBlocked},
+	StateStarting:         {StateRunning, StateHealthy, StateUnhealthy, StateStopping, StateExited, StateFailed},
+	StateRunning:          {StateStopping, StateExited, StateFailed},
+	StateHealthy:          {StateUnhealthy, StateStopping, StateExited, StateFailed},
+	StateUnhealthy:        {StateHealthy, StateStopping, StateExited, StateFailed},
+	StateStopping:         {StateExited, StateFailed},
+	StateExited:           {StateStarting, StateBackoff, StateBlocked},
+	StateFailed:           {StateStarting, StateBackoff, StateBlocked},
+	StateBackoff:          {StateStarting, StateExited, StateBlocked},
+	StateBlocked:          {StateStarting, StateExited, StateDependencyFailed},
+	StateDependencyFailed: {StateStarting, StateBlocked},
+}
+
+// ErrInvalidTransition is returned when a state change is not allowed.
+var ErrInvalidTransition = errors.New("invalid state transition")
+
+// canTransition reports whether from -> to is a valid state change.
+func canTransition(from, to State) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Event describes a single state transition of a process.
+type Event struct {
+	Name string
+	From State
+	To   State
+	Err  error
+	At   time.Time
+}
+
+// setStateLocked moves the process to a new state and publishes the change.
+// The caller must hold p.mu.
+func (p *Process) setStateLocked(to State, err error) error {
+	from := p.state
+	if from == to {
+		return nil
+	}
+	if !canTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+
+	p.state = to
+	switch to {
+	case StateStarting:
+		p.err = nil
+	case StateFailed, StateDependencyFailed:
+		p.err = err
+	}
+
+	// Wake up anyone blocked in Changed()
+	close(p.changed)
+	p.changed = make(chan struct{})
+
+	event := Event{Name: p.Config.Name, From: from, To: to, Err: err, At: time.Now()}
+	select {
+	case p.Events <- event:
+	default:
+		// Listener is behind; the current state is always available via State()
+	}
+	return nil
+}
+
+// setState is setStateLocked for callers that don't hold p.mu.
+func (p *Process) setState(to State, err error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.setStateLocked(to, err)
+}
+
+// State returns the current lifecycle state.
+func (p *Process) State() State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+// Err returns the error of the last failed run, if any.
+func (p *Process) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Changed returns a channel that is closed on the next state transition.
+func (p *Process) Changed() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.changed
+}
+
+// Ready reports whether dependents may start: completed successfully for
+// oneshot tasks, healthy if the task has a health check, running otherwise.
+func (p *Process) Ready() bool {
+	return p.Satisfies("")
+}
+
+// Satisfies reports whether the task meets a depends_on condition. An empty
+// condition is the default of Ready.
+func (p *Process) Satisfies(condition string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch condition {
+	case config.ConditionStarted:
+		return p.aliveLocked()
+	case config.ConditionHealthy:
+		return p.state == StateHealthy
+	case config.ConditionCompleted:
+		return p.completedLocked()
+	}
+	if p.Config.Type == config.TaskOneshot {
+		return p.completedLocked()
+	}
+	if p.Config.HealthCheck != nil {
+		return p.state == StateHealthy
+	}
+	return p.state == StateRunning
+}
+
+// startedSince reports whether a run was launched at or after t.
+func (p *Process) startedSince(t time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.startedAt.IsZero() && !p.startedAt.Before(t)
+}
+
+// Completed reports whether the last run finished on its own with status 0
+// and the task has not been started again since.
+func (p *Process) Completed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.completedLocked()
+}
+
+// completedLocked is Completed for callers holding p.mu.
+func (p *Process) completedLocked() bool {
+	if p.aliveLocked() || len(p.history) == 0 {
+		return false
+	}
+	// The last run must be the current one, not one from before a restart
+	run := p.history[len(p.history)-1]
+	return run.StartedAt.Equal(p.startedAt) && run.ExitCode == 0 && !run.Requested
+}
@@ -0,0 +1,162 @@
+package process
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to State
+		want     bool
+	}{
+		{StatePending, StateStarting, true},
+		{StatePending, StateBlocked, true},
+		{StatePending, StateRunning, false},
+		{StateStarting, StateHealthy, true},
+		{StateRunning, StateHealthy, false},
+		{StateHealthy, StateUnhealthy, true},
+		{StateUnhealthy, StateHealthy, true},
+		{StateStopping, StateExited, true},
+		{StateStopping, StateRunning, false},
+		{StateExited, StateBackoff, true},
+		{StateExited, StateRunning, false},
+		{StateFailed, StateStarting, true},
+		{StateBackoff, StateExited, true},
+		{StateBackoff, StateFailed, false},
+		{StateBlocked, StateDependencyFailed, true},
+		{StateBlocked, StateRunning, false},
+		{StateDependencyFailed, StateBlocked, true},
+		{StateDependencyFailed, StateExited, false},
+	}
+	for _, tt := range tests {
+		if got := canTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("canTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	// Every state can be left, and none lists itself
+	for s := StatePending; s <= StateDependencyFailed; s++ {
+		if len(transitions[s]) == 0 {
+			t.Errorf("no transitions from %s", s)
+		}
+		if canTransition(s, s) {
+			t.Errorf("%s lists itself as a target", s)
+		}
+	}
+}
+
+func TestSetStateLocked(t *testing.T) {
+	failure := errors.New("exit status 1")
+	tests := []struct {
+		name      string
+		from, to  State
+		err       error
+		prevErr   error
+		wantState State
+		wantErr   error // Err() afterwards
+		invalid   bool
+		event     bool
+	}{
+		{"start", StatePending, StateStarting, nil, nil, StateStarting, nil, false, true},
+		{"fail", StateRunning, StateFailed, failure, nil, StateFailed, failure, false, true},
+		{"dependency failed", StateBlocked, StateDependencyFailed, failure, nil, StateDependencyFailed, failure, false, true},
+		{"restart clears error", StateFailed, StateStarting, nil, failure, StateStarting, nil, false, true},
+		{"exit keeps error", StateStopping, StateExited, nil, failure, StateExited, failure, false, true},
+		{"same state", StateRunning, StateRunning, nil, nil, StateRunning, nil, false, false},
+		{"invalid", StateExited, StateRunning, nil, nil, StateExited, nil, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProcess(config.Task{Name: "app"})
+			p.state, p.err = tt.from, tt.prevErr
+			changed := p.Changed()
+
+			err := p.setState(tt.to, tt.err)
+			if tt.invalid != errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("setState = %v, invalid %v", err, tt.invalid)
+			}
+			if state := p.State(); state != tt.wantState {
+				t.Errorf("state = %s, want %s", state, tt.wantState)
+			}
+			if err := p.Err(); err != tt.wantErr {
+				t.Errorf("Err() = %v, want %v", err, tt.wantErr)
+			}
+
+			select {
+			case <-changed:
+				if !tt.event {
+					t.Error("Changed() closed without a transition")
+				}
+			default:
+				if tt.event {
+					t.Error("Changed() not closed")
+				}
+			}
+			select {
+			case e := <-p.Events:
+				if !tt.event {
+					t.Errorf("unexpected event %s -> %s", e.From, e.To)
+				} else if e.Name != "app" || e.From != tt.from || e.To != tt.to || e.Err != tt.err {
+					t.Errorf("event = %+v", e)
+				}
+			default:
+				if tt.event {
+					t.Error("no event published")
+				}
+			}
+		})
+	}
+}
+
+// TestSetStateConcurrent cycles through states from several goroutines while
+// another one follows the changes; run it with -race.
+func TestSetStateConcurrent(t *testing.T) {
+	p := NewProcess(config.Task{Name: "app"})
+	cycle := []State{StateStarting, StateRunning, StateStopping, StateExited}
+
+	var writers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for j := 0; j < 500; j++ {
+				_ = p.setState(cycle[j%len(cycle)], nil)
+			}
+		}()
+	}
+
+	stop := make(chan struct{})
+	invalid := make(chan Event, cap(p.Events))
+	var reader sync.WaitGroup
+	reader.Add(1)
+	go func() {
+		defer reader.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-p.Changed():
+				_ = p.State()
+			case e := <-p.Events:
+				if !canTransition(e.From, e.To) {
+					select {
+					case invalid <- e:
+					default:
+					}
+				}
+			}
+		}
+	}()
+	writers.Wait()
+	close(stop)
+	reader.Wait()
+
+	close(invalid)
+	for e := range invalid {
+		t.Errorf("published invalid transition %s -> %s", e.From, e.To)
+	}
+}
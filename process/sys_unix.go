@@ -0,0 +1,112 @@
+//go:build !windows
+
+package process
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/creack/pty"
+	"github.com/kuo-hm/devdeck/config"
+	"golang.org/x/sys/unix"
+)
+
+var signalNames = map[string]syscall.Signal{
+	"SIGTERM": syscall.SIGTERM,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+	"SIGTSTP": syscall.SIGTSTP,
+}
+
+// parseSignal resolves a stop_signal value such as "SIGTERM" or "term".
+// An empty name resolves to SIGTERM.
+func parseSignal(name string) (os.Signal, bool) {
+	name, ok := config.StopSignalName(name)
+	if !ok {
+		return syscall.SIGTERM, false
+	}
+	return signalNames[name], true
+}
+
+// lookupSignal returns the signal called name, e.g. "SIGINT".
+func lookupSignal(name string) (os.Signal, bool) {
+	sig, ok := signalNames[name]
+	return sig, ok
+}
+
+// setProcessGroup makes the task the leader of a new process group so that
+// everything it spawns can be signaled at once.
+func setProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalGroup delivers sig to the whole process group led by proc.
+func signalGroup(proc *os.Process, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return proc.Signal(sig)
+	}
+	if err := syscall.Kill(-proc.Pid, s); err != nil {
+		// Group may be gone already, signal the leader directly
+		return proc.Signal(sig)
+	}
+	return nil
+}
+
+// killOrphans sends SIGKILL to whatever is left in the process group pgid
+// after its leader exited.
+func killOrphans(pgid int) {
+	_ = syscall.Kill(-pgid, syscall.SIGKILL)
+}
+
+// inGroup reports whether pid belongs to the process group pgid.
+func inGroup(pid int32, pgid int) bool {
+	g, err := syscall.Getpgid(int(pid))
+	return err == nil && g == pgid
+}
+
+// exitSignal returns the name of the signal that terminated the process, if any.
+func exitSignal(state *os.ProcessState) string {
+	if state == nil {
+		return ""
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+	sig := status.Signal()
+	for name, s := range signalNames {
+		if s == sig {
+			return name
+		}
+	}
+	return sig.String()
+}
+
+// startPTY starts c with a new pseudo-terminal as its controlling terminal
+// and returns the master side. The child leads its own session and process
+// group, so signalGroup works as for piped tasks.
+func startPTY(c *exec.Cmd, cols, rows int) (*os.File, error) {
+	return pty.StartWithSize(c, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// resizePTY changes the window size of the terminal behind master. It goes
+// through SyscallConn rather than Fd, which races with the reader's Close.
+func resizePTY(master *os.File, cols, rows int) error {
+	conn, err := master.SyscallConn()
+	if err != nil {
+		return err
+	}
+	size := &unix.Winsize{Col: uint16(cols), Row: uint16(rows)}
+	if ctlErr := conn.Control(func(fd uintptr) {
+		err = unix.IoctlSetWinsize(int(fd), unix.TIOCSWINSZ, size)
+	}); ctlErr != nil {
+		return ctlErr
+	}
+	return err
+}
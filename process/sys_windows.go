@@ -0,0 +1,57 @@
+//go:build windows
+
+package process
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+
+	"github.com/kuo-hm/devdeck/config"
+)
+
+// parseSignal accepts the same names as on Unix, but Windows cannot deliver
+// them to other processes, so every stop ends up as a kill.
+func parseSignal(name string) (os.Signal, bool) {
+	_, ok := config.StopSignalName(name)
+	return os.Kill, ok
+}
+
+// lookupSignal always fails, Windows can't deliver signals to other processes.
+func lookupSignal(name string) (os.Signal, bool) {
+	return nil, false
+}
+
+// setProcessGroup is a no-op on Windows; the process tree is walked instead.
+func setProcessGroup(c *exec.Cmd) {}
+
+// signalGroup kills the task; Windows has no process groups to signal.
+func signalGroup(proc *os.Process, sig os.Signal) error {
+	return proc.Kill()
+}
+
+// killOrphans is a no-op on Windows, which has no process groups.
+func killOrphans(pgid int) {}
+
+// inGroup always reports false so that every descendant is killed explicitly.
+func inGroup(pid int32, pgid int) bool {
+	return false
+}
+
+// exitSignal always returns "" since Windows processes are not terminated by signals.
+func exitSignal(state *os.ProcessState) string {
+	return ""
+}
+
+// errNoPTY is returned for tasks with tty: true, which need a Unix pty.
+var errNoPTY = errors.New("tty is not supported on Windows")
+
+// startPTY always fails on Windows.
+func startPTY(c *exec.Cmd, cols, rows int) (*os.File, error) {
+	return nil, errNoPTY
+}
+
+// resizePTY always fails on Windows.
+func resizePTY(master *os.File, cols, rows int) error {
+	return errNoPTY
+}
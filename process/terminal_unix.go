@@ -0,0 +1,148 @@
+//go:build !windows
+
+package process
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/hinshun/vt10x"
+)
+
+// Glyph attributes set by vt10x (unexported there).
+const (
+	attrReverse   = 1 << 0
+	attrUnderline = 1 << 1
+	attrBold      = 1 << 2
+	attrItalic    = 1 << 4
+	attrBlink     = 1 << 5
+)
+
+// Terminal is the screen of a task running in a pseudo-terminal. Output is
+// fed through a VT100 emulator so cursor movement, clearing and redraws end
+// up as the program intended. It is safe for concurrent use.
+type Terminal struct {
+	mu      sync.Mutex
+	vt      vt10x.Terminal
+	pending []byte // Incomplete UTF-8 sequence from the last write
+}
+
+// NewTerminal creates a screen of the given size. Replies to terminal queries
+// (cursor position, device attributes) are written to w.
+func NewTerminal(cols, rows int, w io.Writer) *Terminal {
+	return &Terminal{vt: vt10x.New(vt10x.WithSize(cols, rows), vt10x.WithWriter(w))}
+}
+
+// Write feeds raw program output to the emulator.
+func (t *Terminal) Write(data []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	buf := append(t.pending, data...)
+	n, err := t.vt.Write(buf)
+	t.pending = append([]byte(nil), buf[n:]...)
+	return len(data), err
+}
+
+// Resize changes the screen size.
+func (t *Terminal) Resize(cols, rows int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.vt.Resize(cols, rows)
+}
+
+// Size returns the screen size.
+func (t *Terminal) Size() (cols, rows int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.vt.Size()
+}
+
+// View renders the screen as text with ANSI color sequences. The cursor is
+// shown as a reversed cell when the program has it visible.
+func (t *Terminal) View() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.vt.Lock()
+	defer t.vt.Unlock()
+
+	cols, rows := t.vt.Size()
+	cursor := t.vt.Cursor()
+	showCursor := t.vt.CursorVisible()
+
+	var b strings.Builder
+	for y := 0; y < rows; y++ {
+		if y > 0 {
+			b.WriteString("\n")
+		}
+
+		// Skip trailing blanks so the pane can clip and pad the line
+		end := cols
+		for end > 0 && blank(t.vt.Cell(end-1, y)) && !(showCursor && cursor.Y == y && cursor.X == end-1) {
+			end--
+		}
+
+		last := ""
+		for x := 0; x < end; x++ {
+			glyph := t.vt.Cell(x, y)
+			if showCursor && cursor.Y == y && cursor.X == x {
+				glyph.Mode ^= attrReverse
+			}
+			if sgr := glyphSGR(glyph); sgr != last {
+				b.WriteString(sgr)
+				last = sgr
+			}
+			if glyph.Char == 0 {
+				b.WriteRune(' ')
+			} else {
+				b.WriteRune(glyph.Char)
+			}
+		}
+		if last != "" {
+			b.WriteString("\x1b[0m")
+		}
+	}
+	return b.String()
+}
+
+// blank reports whether a cell renders as empty space.
+func blank(g vt10x.Glyph) bool {
+	return (g.Char == ' ' || g.Char == 0) && g.BG == vt10x.DefaultBG && g.Mode&attrReverse == 0
+}
+
+// glyphSGR returns the escape sequence selecting the attributes of g, or ""
+// for the default style.
+func glyphSGR(g vt10x.Glyph) string {
+	fg, bg := g.FG, g.BG
+	var params []string
+	if g.Mode&attrReverse != 0 {
+		// vt10x already swapped the colors, undo it and let the terminal reverse
+		fg, bg = bg, fg
+		params = append(params, "7")
+	}
+	if g.Mode&attrBold != 0 {
+		params = append(params, "1")
+	}
+	if g.Mode&attrItalic != 0 {
+		params = append(params, "3")
+	}
+	if g.Mode&attrUnderline != 0 {
+		params = append(params, "4")
+	}
+	if g.Mode&attrBlink != 0 {
+		params = append(params, "5")
+	}
+	if fg < 256 {
+		params = append(params, fmt.Sprintf("38;5;%d", fg))
+	}
+	if bg < 256 {
+		params = append(params, fmt.Sprintf("48;5;%d", bg))
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "\x1b[0;" + strings.Join(params, ";") + "m"
+}
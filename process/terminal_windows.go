@@ -0,0 +1,32 @@
+//go:build windows
+
+package process
+
+import "io"
+
+// Terminal is the screen of a task running in a pseudo-terminal. Windows has
+// no pty support, so it stays empty.
+type Terminal struct{}
+
+// NewTerminal creates an empty screen.
+func NewTerminal(cols, rows int, w io.Writer) *Terminal {
+	return &Terminal{}
+}
+
+// Write discards data.
+func (t *Terminal) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+// Resize is a no-op.
+func (t *Terminal) Resize(cols, rows int) {}
+
+// Size returns 0, 0.
+func (t *Terminal) Size() (cols, rows int) {
+	return 0, 0
+}
+
+// View returns an empty screen.
+func (t *Terminal) View() string {
+	return ""
+}
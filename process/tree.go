@@ -0,0 +1,87 @@
+package process
+
+import (
+	"os"
+	"time"
+
+	ps "github.com/shirou/gopsutil/v3/process"
+)
+
+// descendants returns the PIDs of every process below pid in the process tree.
+// It is used as a fallback for processes that left the task's process group.
+func descendants(pid int32) []int32 {
+	procs, err := ps.Processes()
+	if err != nil {
+		return nil
+	}
+
+	children := make(map[int32][]int32)
+	for _, proc := range procs {
+		ppid, err := proc.Ppid()
+		if err != nil {
+			continue
+		}
+		children[ppid] = append(children[ppid], proc.Pid)
+	}
+
+	var result []int32
+	queue := []int32{pid}
+	seen := map[int32]bool{pid: true}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range children[current] {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			result = append(result, child)
+			queue = append(queue, child)
+		}
+	}
+	return result
+}
+
+// signalEscaped delivers sig to the PIDs that are not part of the process group
+// pgid, since the group signal does not reach them.
+func signalEscaped(pids []int32, pgid int, sig os.Signal) {
+	for _, pid := range pids {
+		if inGroup(pid, pgid) {
+			continue
+		}
+		if proc, err := os.FindProcess(int(pid)); err == nil {
+			_ = proc.Signal(sig)
+		}
+	}
+}
+
+// alivePids returns the PIDs that still exist and are not zombies.
+func alivePids(pids []int32) []int32 {
+	var alive []int32
+	for _, pid := range pids {
+		proc, err := ps.NewProcess(pid)
+		if err != nil {
+			continue
+		}
+		if status, err := proc.Status(); err == nil && len(status) > 0 && status[0] == ps.Zombie {
+			continue
+		}
+		alive = append(alive, pid)
+	}
+	return alive
+}
+
+// waitPids polls until none of pids is alive or the deadline passes.
+// It reports whether all of them are gone.
+func waitPids(pids []int32, deadline time.Time) bool {
+	for {
+		pids = alivePids(pids)
+		if len(pids) == 0 {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kuo-hm/devdeck/process"
+)
+
+// detachKey ends attach mode; every other key goes to the task.
+const detachKey = "ctrl+]"
+
+// keySequences maps special keys to what an xterm sends for them.
+var keySequences = map[tea.KeyType]string{
+	tea.KeyUp:         "\x1b[A",
+	tea.KeyDown:       "\x1b[B",
+	tea.KeyRight:      "\x1b[C",
+	tea.KeyLeft:       "\x1b[D",
+	tea.KeyShiftTab:   "\x1b[Z",
+	tea.KeyHome:       "\x1b[H",
+	tea.KeyEnd:        "\x1b[F",
+	tea.KeyPgUp:       "\x1b[5~",
+	tea.KeyPgDown:     "\x1b[6~",
+	tea.KeyDelete:     "\x1b[3~",
+	tea.KeyInsert:     "\x1b[2~",
+	tea.KeySpace:      " ",
+	tea.KeyCtrlUp:     "\x1b[1;5A",
+	tea.KeyCtrlDown:   "\x1b[1;5B",
+	tea.KeyCtrlRight:  "\x1b[1;5C",
+	tea.KeyCtrlLeft:   "\x1b[1;5D",
+	tea.KeyShiftUp:    "\x1b[1;2A",
+	tea.KeyShiftDown:  "\x1b[1;2B",
+	tea.KeyShiftRight: "\x1b[1;2C",
+	tea.KeyShiftLeft:  "\x1b[1;2D",
+	tea.KeyF1:         "\x1bOP",
+	tea.KeyF2:         "\x1bOQ",
+	tea.KeyF3:         "\x1bOR",
+	tea.KeyF4:         "\x1bOS",
+	tea.KeyF5:         "\x1b[15~",
+	tea.KeyF6:         "\x1b[17~",
+	tea.KeyF7:         "\x1b[18~",
+	tea.KeyF8:         "\x1b[19~",
+	tea.KeyF9:         "\x1b[20~",
+	tea.KeyF10:        "\x1b[21~",
+	tea.KeyF11:        "\x1b[23~",
+	tea.KeyF12:        "\x1b[24~",
+}
+
+// keyBytes converts a key press back into the bytes a terminal would send.
+// Tasks without a tty get "\n" for Enter, like a line-buffered program expects.
+func keyBytes(msg tea.KeyMsg, tty bool) []byte {
+	var seq string
+	switch {
+	case msg.Type == tea.KeyRunes:
+		seq = string(msg.Runes)
+	case msg.Type == tea.KeyEnter && !tty:
+		seq = "\n"
+	case msg.Type >= 0 && msg.Type <= 127:
+		// Control characters (ctrl+c, tab, enter, esc, backspace) are their own code
+		seq = string(rune(msg.Type))
+	default:
+		seq = keySequences[msg.Type]
+	}
+	if seq == "" {
+		return nil
+	}
+	if msg.Alt && !msg.Paste {
+		seq = "\x1b" + seq
+	}
+	return []byte(seq)
+}
+
+// keySignals maps the keys a terminal turns into signals to those signals.
+// Tasks without a tty have no terminal to do that, so DevDeck sends them.
+var keySignals = map[tea.KeyType]string{
+	tea.KeyCtrlC:         "SIGINT",
+	tea.KeyCtrlBackslash: "SIGQUIT",
+	tea.KeyCtrlZ:         "SIGTSTP",
+}
+
+// keyInput is a key press forwarded to a task: bytes to write, or the name of
+// a signal to send.
+type keyInput struct {
+	data   []byte
+	signal string
+}
+
+// attachment forwards key presses to a task in order, off the UI loop, so a
+// task that stops reading its input can't freeze DevDeck.
+type attachment struct {
+	proc  *process.Process
+	input chan keyInput
+}
+
+// attach starts forwarding input to p.
+func attach(p *process.Process) *attachment {
+	a := &attachment{proc: p, input: make(chan keyInput, 256)}
+	go func() {
+		for key := range a.input {
+			if key.signal != "" {
+				_ = a.proc.Signal(key.signal)
+			} else {
+				_ = a.proc.WriteInput(key.data)
+			}
+		}
+	}()
+	return a
+}
+
+// sendKey queues a key press, as a signal for keys that raise one in a
+// terminal when the task has none. Keys are dropped if the task is far behind.
+func (a *attachment) sendKey(msg tea.KeyMsg) {
+	key := keyInput{data: keyBytes(msg, a.proc.Config.TTY)}
+	if !a.proc.Config.TTY {
+		key.signal = keySignals[msg.Type]
+	}
+	if key.data == nil && key.signal == "" {
+		return
+	}
+	select {
+	case a.input <- key:
+	default:
+	}
+}
+
+// detach stops forwarding.
+func (a *attachment) detach() {
+	close(a.input)
+}
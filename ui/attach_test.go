@@ -0,0 +1,25 @@
+//go:build !windows
+
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kuo-hm/devdeck/config"
+	"github.com/kuo-hm/devdeck/process"
+)
+
+func TestAttachCtrlCWithoutTTY(t *testing.T) {
+	_, byName := startModel(t, []config.Task{{Name: "repl", Args: []string{"sleep", "300"}}}, "repl")
+	p := byName["repl"]
+
+	a := attach(p)
+	defer a.detach()
+	a.sendKey(tea.KeyMsg{Type: tea.KeyCtrlC})
+
+	waitState(t, p, process.StateFailed)
+	if run, _ := p.LastRun(); run.Signal != "SIGINT" {
+		t.Errorf("run ended by %q, want SIGINT", run.Signal)
+	}
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kuo-hm/devdeck/process"
+)
+
+// withDependents returns target followed by every task that depends on it,
+// directly or not, in start order.
+func (m Model) withDependents(target *process.Process) []*process.Process {
+	affected := map[string]bool{target.Config.Name: true}
+	cascade := []*process.Process{target}
+	// In start order a task comes after all of its dependencies, so one pass
+	// finds the whole chain
+	for _, p := range m.inStartOrder() {
+		if affected[p.Config.Name] {
+			continue
+		}
+		for _, dep := range p.Config.DependsOn {
+			if affected[dep.Name] {
+				affected[p.Config.Name] = true
+				cascade = append(cascade, p)
+				break
+			}
+		}
+	}
+	return cascade
+}
+
+// restartable keeps the target of a cascade and the dependents that are up
+// or waiting to start. Tasks that were stopped, never started or whose
+// dependencies failed are left alone.
+func restartable(cascade []*process.Process) []*process.Process {
+	kept := []*process.Process{cascade[0]}
+	for _, p := range cascade[1:] {
+		if state := p.State(); state.Alive() || state == process.StateBlocked {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// restartInOrder restarts the first process together with the others, which
+// depend on it. The dependents are stopped first, in reverse dependency order,
+// and each starts again once its own dependencies are ready.
+func restartInOrder(procs []*process.Process, tasks *process.Registry) tea.Cmd {
+	return func() tea.Msg {
+		stopAll(procs[1:])
+		_ = procs[0].Restart()
+		for _, p := range procs[1:] {
+			go func(p *process.Process) {
+				_ = p.StartAfter(tasks)
+			}(p)
+		}
+		return nil
+	}
+}
+
+// stopAll stops the given processes and waits for all of them. A task is
+// stopped once every task depending on it has exited; unrelated tasks stop
+// in parallel.
+func stopAll(procs []*process.Process) {
+	done := make(map[string]chan struct{}, len(procs))
+	for _, p := range procs {
+		done[p.Config.Name] = make(chan struct{})
+	}
+	dependents := make(map[string][]chan struct{})
+	for _, p := range procs {
+		for _, dep := range p.Config.DependsOn {
+			if _, ok := done[dep.Name]; ok {
+				dependents[dep.Name] = append(dependents[dep.Name], done[p.Config.Name])
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, p := range procs {
+		wg.Add(1)
+		go func(p *process.Process) {
+			defer wg.Done()
+			for _, d := range dependents[p.Config.Name] {
+				<-d
+			}
+			_ = p.Stop()
+			close(done[p.Config.Name])
+		}(p)
+	}
+	wg.Wait()
+}
+
+// killAll kills the given processes in parallel. It returns once all of them
+// are gone, or after process.KillWait at the latest, so that no task outlives
+// DevDeck.
+func killAll(procs []*process.Process) {
+	var wg sync.WaitGroup
+	for _, p := range procs {
+		wg.Add(1)
+		go func(p *process.Process) {
+			defer wg.Done()
+			_ = p.Kill()
+		}(p)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(process.KillWait):
+	}
+}
@@ -0,0 +1,126 @@
+//go:build !windows
+
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kuo-hm/devdeck/config"
+	"github.com/kuo-hm/devdeck/process"
+)
+
+// waitState waits until p is in state want.
+func waitState(t *testing.T, p *process.Process, want process.State) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		changed := p.Changed()
+		if p.State() == want {
+			return
+		}
+		select {
+		case <-changed:
+		case <-deadline:
+			t.Fatalf("%s is %s, want %s", p.Config.Name, p.State(), want)
+		}
+	}
+}
+
+// startModel creates a model for tasks and starts the named ones.
+func startModel(t *testing.T, tasks []config.Task, start ...string) (Model, map[string]*process.Process) {
+	t.Helper()
+	m := InitialModel(&config.Config{Tasks: tasks})
+	byName := make(map[string]*process.Process)
+	for _, p := range m.processes {
+		byName[p.Config.Name] = p
+		t.Cleanup(func() { _ = p.Kill() })
+	}
+	for _, name := range start {
+		if err := byName[name].Start(); err != nil {
+			t.Fatalf("Start %s: %v", name, err)
+		}
+	}
+	return m, byName
+}
+
+func TestRestartInOrder(t *testing.T) {
+	sleep := []string{"sleep", "300"}
+	m, byName := startModel(t, []config.Task{
+		{Name: "db", Args: sleep},
+		{Name: "api", Args: sleep, DependsOn: config.Dependencies{{Name: "db", Condition: config.ConditionStarted}}},
+		{Name: "worker", Args: sleep, DependsOn: config.Dependencies{{Name: "api"}}},
+		{Name: "cron", Args: sleep, DependsOn: config.Dependencies{{Name: "db"}}},
+	}, "db", "api", "cron")
+	db, api, worker, cron := byName["db"], byName["api"], byName["worker"], byName["cron"]
+	if err := cron.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	dbStarted, apiStarted := db.StartedAt(), api.StartedAt()
+
+	restartInOrder(restartable(m.withDependents(db)), m.tasks)()
+
+	waitState(t, api, process.StateRunning)
+	dbRun, _ := db.LastRun()
+	apiRun, _ := api.LastRun()
+	if apiRun.StoppedAt.After(dbRun.StoppedAt) {
+		t.Errorf("api stopped at %s, after db at %s", apiRun.StoppedAt, dbRun.StoppedAt)
+	}
+	if !db.StartedAt().After(dbStarted) || !api.StartedAt().After(apiStarted) {
+		t.Error("db and api were not restarted")
+	}
+	if api.StartedAt().Before(db.StartedAt()) {
+		t.Errorf("api started at %s, before db at %s", api.StartedAt(), db.StartedAt())
+	}
+	if state := worker.State(); state != process.StatePending {
+		t.Errorf("worker, never started, is %s", state)
+	}
+	if state := cron.State(); state != process.StateExited {
+		t.Errorf("cron, stopped by the user, is %s", state)
+	}
+}
+
+func TestRestartInOrderBlockedDependent(t *testing.T) {
+	sleep := []string{"sleep", "300"}
+	m, byName := startModel(t, []config.Task{
+		{Name: "db", Args: sleep},
+		{Name: "cache", Args: sleep},
+		{Name: "api", Args: sleep, DependsOn: config.Dependencies{{Name: "db"}, {Name: "cache"}}},
+		{Name: "worker", Args: sleep, DependsOn: config.Dependencies{{Name: "db"}}},
+	}, "db", "cache", "api", "worker")
+	db, cache, api, worker := byName["db"], byName["cache"], byName["api"], byName["worker"]
+	if err := cache.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	workerStarted := worker.StartedAt()
+
+	restartInOrder(restartable(m.withDependents(db)), m.tasks)()
+
+	// api waits for cache, which doesn't hold up worker
+	waitState(t, worker, process.StateRunning)
+	if !worker.StartedAt().After(workerStarted) {
+		t.Error("worker was not restarted")
+	}
+	waitState(t, api, process.StateBlocked)
+	if on := api.BlockedOn(); on != "cache" {
+		t.Errorf("api is waiting for %q, want cache", on)
+	}
+}
+
+func TestKillAll(t *testing.T) {
+	var procs []*process.Process
+	for _, name := range []string{"api", "worker"} {
+		p := process.NewProcess(config.Task{Name: name, Args: []string{"sh", "-c", "trap '' TERM; sleep 300 & wait"}})
+		if err := p.Start(); err != nil {
+			t.Fatalf("Start %s: %v", name, err)
+		}
+		procs = append(procs, p)
+	}
+
+	killAll(procs)
+	for _, p := range procs {
+		if state := p.State(); state.Alive() {
+			t.Errorf("%s is %s after killAll", p.Config.Name, state)
+		}
+	}
+}
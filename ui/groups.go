@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kuo-hm/devdeck/config"
+	"github.com/kuo-hm/devdeck/process"
+)
+
+// inGroup reports whether p is a member of group.
+func inGroup(p *process.Process, group string) bool {
+	for _, g := range p.Config.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// collectGroups returns the groups used by tasks, sorted.
+func collectGroups(tasks []config.Task) []string {
+	groupSet := make(map[string]bool)
+	for _, t := range tasks {
+		for _, g := range t.Groups {
+			groupSet[g] = true
+		}
+	}
+	var groups []string
+	for g := range groupSet {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// groupMembers returns the members of group in start order.
+func (m Model) groupMembers(group string) []*process.Process {
+	var members []*process.Process
+	for _, p := range m.inStartOrder() {
+		if inGroup(p, group) {
+			members = append(members, p)
+		}
+	}
+	return members
+}
+
+// groupAction runs a group menu action on every member of group. Members
+// start once their dependencies are ready and stop after their dependents.
+func (m Model) groupAction(action, group string) tea.Cmd {
+	members := m.groupMembers(group)
+	tasks := m.tasks
+
+	var cmds []tea.Cmd
+	switch action {
+	case "u":
+		for _, p := range members {
+			cmds = append(cmds, startProcess(p, tasks))
+		}
+	case "x":
+		cmds = append(cmds, func() tea.Msg {
+			stopAll(members)
+			return nil
+		})
+	case "r", "enter":
+		for _, p := range members {
+			p.Logs.Append(process.LogLine{Stream: process.StreamSystem, Text: fmt.Sprintf("--- GROUP RESTART (%s) ---", group)})
+		}
+		cmds = append(cmds, func() tea.Msg {
+			stopAll(members)
+			for _, p := range members {
+				go func(p *process.Process) {
+					_ = p.StartAfter(tasks)
+				}(p)
+			}
+			return nil
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// mergedLog interleaves the output of every member of a group.
+type mergedLog struct {
+	group  string
+	buffer *process.LogBuffer
+}
+
+// newMergedLog collects the lines the members of group have printed so far,
+// oldest first, each tagged with its task name.
+func (m Model) newMergedLog(group string) *mergedLog {
+	var lines []process.LogLine
+	for _, p := range m.processes {
+		if !inGroup(p, group) {
+			continue
+		}
+		for _, line := range p.Logs.All() {
+			lines = append(lines, tagLine(p, line))
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Time.Before(lines[j].Time) })
+
+	merged := &mergedLog{group: group, buffer: process.NewLogBuffer(process.DefaultMaxLogLines)}
+	for _, line := range lines {
+		merged.buffer.Append(line)
+	}
+	return merged
+}
+
+// tagLine prefixes a line with the name of the task that printed it.
+func tagLine(p *process.Process, line process.LogLine) process.LogLine {
+	line.Text = "[" + p.Config.Name + "] " + line.Text
+	return line
+}
+
+// visible reports whether the task at index i is shown in the task list.
+func (m Model) visible(i int) bool {
+	return m.focusGroup == "" || inGroup(m.processes[i], m.focusGroup)
+}
+
+// moveCursor moves the cursor to the next visible task in direction dir
+// (-1 or 1) and reports whether it moved.
+func (m *Model) moveCursor(dir int) bool {
+	for i := m.cursor + dir; i >= 0 && i < len(m.processes); i += dir {
+		if m.visible(i) {
+			m.cursor = i
+			return true
+		}
+	}
+	return false
+}
+
+// listRow is a line of the task list: a group header, or the task at index.
+type listRow struct {
+	header string
+	index  int
+}
+
+// listRows returns the lines of the task list. Visible tasks are listed in
+// order, each run of tasks sharing a first group under a header.
+func (m Model) listRows() []listRow {
+	var rows []listRow
+	lastGroup := ""
+	for i, p := range m.processes {
+		if !m.visible(i) {
+			continue
+		}
+		group := ""
+		if len(p.Config.Groups) > 0 {
+			group = p.Config.Groups[0]
+		}
+		if group != "" && group != lastGroup {
+			rows = append(rows, listRow{header: group})
+		}
+		lastGroup = group
+		rows = append(rows, listRow{index: i})
+	}
+	return rows
+}
@@ -0,0 +1,354 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kuo-hm/devdeck/process"
+)
+
+// streamFilter selects which output streams a log view shows. DevDeck's own
+// lines are always shown.
+type streamFilter int
+
+const (
+	filterAll streamFilter = iota
+	filterStdout
+	filterStderr
+)
+
+func (f streamFilter) String() string {
+	switch f {
+	case filterStdout:
+		return "stdout"
+	case filterStderr:
+		return "stderr"
+	default:
+		return "all"
+	}
+}
+
+// next returns the filter that follows f when cycling with the toggle key.
+func (f streamFilter) next() streamFilter {
+	return (f + 1) % 3
+}
+
+// match reports whether line passes the filter.
+func (f streamFilter) match(line process.LogLine) bool {
+	switch f {
+	case filterStdout:
+		return line.Stream != process.StreamStderr
+	case filterStderr:
+		return line.Stream != process.StreamStdout
+	default:
+		return true
+	}
+}
+
+// scanChunk is how many lines are read at a time when skipping filtered lines.
+const scanChunk = 256
+
+// logView is a scrollable view over a process LogBuffer. Unlike a plain
+// viewport it never holds the whole history: only the visible window is read
+// from the buffer and rendered.
+type logView struct {
+	Width  int
+	Height int
+
+	buffer *process.LogBuffer
+	screen *process.Terminal // Shown instead of the tail for tty tasks
+	query  string            // Search term to highlight
+	filter streamFilter      // Streams to show
+
+	stderrStyle lipgloss.Style // Applied to stderr lines
+
+	follow bool   // Stick to the newest line
+	top    uint64 // Sequence number of the first visible line when not following
+}
+
+func newLogView(width, height int) logView {
+	return logView{Width: width, Height: height, follow: true}
+}
+
+// SetProcess switches the view to the logs of p and scrolls to the bottom.
+func (v *logView) SetProcess(p *process.Process) {
+	v.buffer, v.screen = nil, nil
+	if p != nil {
+		v.buffer, v.screen = p.Logs, p.Screen
+	}
+	v.GotoBottom()
+}
+
+// SetBuffer shows buffer, which belongs to no single process.
+func (v *logView) SetBuffer(buffer *process.LogBuffer) {
+	v.buffer, v.screen = buffer, nil
+	v.GotoBottom()
+}
+
+// SetQuery sets the search term highlighted in the visible lines.
+func (v *logView) SetQuery(query string) {
+	v.query = query
+}
+
+// SetFilter sets the streams shown and scrolls to the bottom.
+func (v *logView) SetFilter(filter streamFilter) {
+	v.filter = filter
+	v.GotoBottom()
+}
+
+// back returns the buffer index of the n-th line before idx that passes the
+// filter, or of the oldest such line if there are fewer than n.
+func (v *logView) back(idx, n int) int {
+	if v.filter == filterAll {
+		if idx-n < 0 {
+			return 0
+		}
+		return idx - n
+	}
+
+	result := idx
+	for idx > 0 && n > 0 {
+		from := idx - scanChunk
+		if from < 0 {
+			from = 0
+		}
+		lines := v.buffer.Slice(from, idx-from)
+		for i := len(lines) - 1; i >= 0 && n > 0; i-- {
+			if v.filter.match(lines[i]) {
+				result = from + i
+				n--
+			}
+		}
+		idx = from
+	}
+	return result
+}
+
+// forward returns the buffer index reached by skipping n lines that pass the
+// filter, starting at idx.
+func (v *logView) forward(idx, n int) int {
+	if v.filter == filterAll {
+		return idx + n
+	}
+
+	total := v.buffer.Len()
+	for idx < total {
+		lines := v.buffer.Slice(idx, scanChunk)
+		for i, line := range lines {
+			if !v.filter.match(line) {
+				continue
+			}
+			if n == 0 {
+				return idx + i
+			}
+			n--
+		}
+		idx += len(lines)
+	}
+	return total
+}
+
+// lastPage returns the buffer index of the first line of the last page.
+func (v *logView) lastPage() int {
+	return v.back(v.buffer.Len(), v.Height)
+}
+
+// start returns the buffer index of the first visible line.
+func (v *logView) start() int {
+	if v.buffer == nil {
+		return 0
+	}
+	last := v.lastPage()
+	if v.follow {
+		return last
+	}
+
+	first := v.buffer.FirstSeq()
+	if v.top < first {
+		// Anchor line was evicted from the ring buffer
+		return 0
+	}
+	idx := int(v.top - first)
+	if idx > last {
+		idx = last
+	}
+	return idx
+}
+
+// scrollTo anchors the view at buffer index idx, following the tail if idx is
+// at or past the last page.
+func (v *logView) scrollTo(idx int) {
+	if v.buffer == nil {
+		return
+	}
+	if idx >= v.lastPage() {
+		v.follow = true
+		return
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	v.follow = false
+	v.top = v.buffer.FirstSeq() + uint64(idx)
+}
+
+// AtBottom reports whether the newest line is visible.
+func (v *logView) AtBottom() bool {
+	if v.follow || v.buffer == nil {
+		return true
+	}
+	return v.start() >= v.lastPage()
+}
+
+// GotoBottom scrolls to the newest line and keeps following it.
+func (v *logView) GotoBottom() {
+	v.follow = true
+}
+
+// GotoTop scrolls to the oldest stored line.
+func (v *logView) GotoTop() {
+	v.scrollTo(0)
+}
+
+// LineUp scrolls up by n lines.
+func (v *logView) LineUp(n int) {
+	if v.buffer == nil {
+		return
+	}
+	v.scrollTo(v.back(v.start(), n))
+}
+
+// LineDown scrolls down by n lines.
+func (v *logView) LineDown(n int) {
+	if v.buffer == nil {
+		return
+	}
+	v.scrollTo(v.forward(v.start(), n))
+}
+
+// Update handles scrolling keys.
+func (v logView) Update(msg tea.Msg) (logView, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return v, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		v.LineUp(1)
+	case "down", "j":
+		v.LineDown(1)
+	case "pgup", "ctrl+b":
+		v.LineUp(v.Height)
+	case "pgdown", "ctrl+f":
+		v.LineDown(v.Height)
+	case "ctrl+u":
+		v.LineUp(v.Height / 2)
+	case "ctrl+d":
+		v.LineDown(v.Height / 2)
+	case "home":
+		v.GotoTop()
+	case "end":
+		v.GotoBottom()
+	}
+	return v, nil
+}
+
+// View renders the visible window of the buffer. For tty tasks the bottom of
+// the log is the terminal screen itself.
+func (v logView) View() string {
+	if v.screen != nil && v.follow && v.filter != filterStderr {
+		vp := viewport.New(v.Width, v.Height)
+		vp.SetContent(v.screen.View())
+		return vp.View()
+	}
+
+	var lines []process.LogLine
+	if v.buffer != nil && v.Height > 0 {
+		lines = v.visibleLines()
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		var base *lipgloss.Style
+		if line.Stream == process.StreamStderr {
+			base = &v.stderrStyle
+		}
+		b.WriteString(highlightLine(line.Text, v.query, base))
+	}
+
+	// Reuse the viewport renderer for clipping and padding to the pane size
+	vp := viewport.New(v.Width, v.Height)
+	vp.SetContent(b.String())
+	return vp.View()
+}
+
+// visibleLines returns the lines on screen, skipping filtered ones.
+func (v logView) visibleLines() []process.LogLine {
+	idx := v.start()
+	if v.filter == filterAll {
+		return v.buffer.Slice(idx, v.Height)
+	}
+
+	var lines []process.LogLine
+	total := v.buffer.Len()
+	for idx < total && len(lines) < v.Height {
+		chunk := v.buffer.Slice(idx, scanChunk)
+		for _, line := range chunk {
+			if v.filter.match(line) && len(lines) < v.Height {
+				lines = append(lines, line)
+			}
+		}
+		idx += len(chunk)
+	}
+	return lines
+}
+
+// highlightLine marks every case-insensitive occurrence of query in line and
+// renders the rest with base, if set.
+func highlightLine(line string, query string, base *lipgloss.Style) string {
+	if !containsFold(line, query) {
+		return render(base, line)
+	}
+	lowQuery := strings.ToLower(query)
+
+	// Prepare highlight style (classic yellow background, black text)
+	hlStyle := lipgloss.NewStyle().Background(lipgloss.Color("#FFFF00")).Foreground(lipgloss.Color("#000000"))
+
+	var sb strings.Builder
+	currentLower := strings.ToLower(line)
+	currentOriginal := line
+
+	for {
+		idx := strings.Index(currentLower, lowQuery)
+		if idx == -1 {
+			sb.WriteString(render(base, currentOriginal))
+			break
+		}
+
+		sb.WriteString(render(base, currentOriginal[:idx]))
+		sb.WriteString(hlStyle.Render(currentOriginal[idx : idx+len(query)]))
+
+		currentLower = currentLower[idx+len(query):]
+		currentOriginal = currentOriginal[idx+len(query):]
+	}
+	return sb.String()
+}
+
+// render applies style to s. Empty strings and a nil style leave s as is.
+func render(style *lipgloss.Style, s string) string {
+	if style == nil || s == "" {
+		return s
+	}
+	return style.Render(s)
+}
+
+// containsFold reports whether line contains query, ignoring case.
+func containsFold(line, query string) bool {
+	return query != "" && strings.Contains(strings.ToLower(line), strings.ToLower(query))
+}
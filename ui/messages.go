@@ -2,11 +2,14 @@ package ui
 
 import (
 	"github.com/kuo-hm/devdeck/config"
+	"github.com/kuo-hm/devdeck/process"
 )
 
+// LogMsg carries a batch of output lines from one process.
 type LogMsg struct {
 	ProcessName string
-	Content     string
+	Lines       []process.LogLine
+	proc        *process.Process // Sender, a replaced task's lines are dropped
 }
 
 type ProcessFinishedMsg struct {
@@ -15,3 +18,15 @@ type ProcessFinishedMsg struct {
 }
 
 type ConfigChangedMsg *config.Config
+
+// ConfigErrorMsg reports a config file change that failed to load. The
+// previous config stays in effect.
+type ConfigErrorMsg struct {
+	Err error
+}
+
+// StateChangedMsg is sent for every lifecycle transition of a process.
+type StateChangedMsg struct {
+	process.Event
+	proc *process.Process
+}
@@ -1,15 +1,12 @@
 package ui
 
 import (
-	"fmt"
+	"reflect"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
-	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 	"github.com/kuo-hm/devdeck/config"
 	"github.com/kuo-hm/devdeck/process"
 	"github.com/shirou/gopsutil/v3/cpu"
@@ -45,16 +42,17 @@ type Model struct {
 	processes         []*process.Process
 	cursor            int
 	ready             bool
-	viewport          viewport.Model
-	secondaryViewport viewport.Model
+	viewport          logView
+	secondaryViewport logView
 	pinnedIndex       int
 	focusedPane       Focus
 	textInput         textinput.Model
 	inputMode         InputMode
 	searchQuery       string
-	matches           []int // Line numbers of search matches
-	matchIndex        int   // Current match index (in matches array)
+	matches           []uint64 // Sequence numbers of search matches
+	matchIndex        int      // Current match index (in matches array)
 	helpVisible       bool
+	detailVisible     bool
 	theme             *config.Theme
 	width             int
 	height            int
@@ -65,6 +63,16 @@ type Model struct {
 	groupMenuVisible bool
 	groupCursor      int
 	groups           []string
+	focusGroup       string     // Only tasks of this group are listed, "" for all
+	merged           *mergedLog // Shown in the main pane instead of the selected task
+
+	quitting bool // Waiting for processes to stop before exiting
+
+	attached *attachment // Task receiving every key press, nil when not attached
+
+	tasks      *process.Registry // Resolves dependencies, follows hot reloads
+	startOrder []string          // Task names, dependencies first
+	configErr  error             // Why the last config reload was rejected
 }
 
 // InitialModel creates the initial state from the configuration.
@@ -94,18 +102,6 @@ func InitialModel(cfg *config.Config) Model {
 	ti.CharLimit = 156
 	ti.Width = 30
 
-	// Collect unique groups
-	groupSet := make(map[string]bool)
-	for _, t := range cfg.Tasks {
-		for _, g := range t.Groups {
-			groupSet[g] = true
-		}
-	}
-	var groups []string
-	for g := range groupSet {
-		groups = append(groups, g)
-	}
-
 	return Model{
 		processes:        processes,
 		cursor:           0,
@@ -114,17 +110,43 @@ func InitialModel(cfg *config.Config) Model {
 		textInput:        ti,
 		inputMode:        InputNone,
 		searchQuery:      "",
-		matches:          []int{},
+		matches:          []uint64{},
 		matchIndex:       -1,
 		theme:            cfg.Theme,
 		cpuUsage:         0.0,
 		memUsage:         0.0,
 		groupMenuVisible: false,
 		groupCursor:      0,
-		groups:           groups,
+		groups:           collectGroups(cfg.Tasks),
+		tasks:            process.NewRegistry(processes),
+		startOrder:       cfg.StartOrder(),
 	}
 }
 
+// sameProcess reports whether two versions of a task run the same way, so
+// that a reload can keep the process. Settings only the UI reads may differ.
+func sameProcess(old, task config.Task) bool {
+	old.Groups = task.Groups
+	old.Autostart = task.Autostart
+	old.RestartDependents = task.RestartDependents
+	return reflect.DeepEqual(old, task)
+}
+
+// inStartOrder returns the processes with every task after its dependencies.
+func (m Model) inStartOrder() []*process.Process {
+	byName := make(map[string]*process.Process, len(m.processes))
+	for _, p := range m.processes {
+		byName[p.Config.Name] = p
+	}
+	ordered := make([]*process.Process, 0, len(m.processes))
+	for _, name := range m.startOrder {
+		if p, ok := byName[name]; ok {
+			ordered = append(ordered, p)
+		}
+	}
+	return ordered
+}
+
 // Command to fetch system stats
 func fetchSystemStats() tea.Msg {
 	// simulate async wait if needed, but here we just sleep then fetch?
@@ -148,18 +170,20 @@ func fetchSystemStats() tea.Msg {
 // Init starts all processes and the activity listener loop.
 func (m Model) Init() tea.Cmd {
 	var cmds []tea.Cmd
-	for _, proc := range m.processes {
+	for _, proc := range m.inStartOrder() {
 		// Activity listener (always start, will block on channel)
-		cmds = append(cmds, waitForActivity(proc.Config.Name, proc.Output))
+		cmds = append(cmds, waitForActivity(proc))
+		cmds = append(cmds, waitForEvent(proc))
 
 		// Start Process Command
 		// We wrap this in a Cmd to allow blocking for dependencies without freezing UI
+		if !proc.Config.ShouldAutostart() {
+			continue
+		}
 		p := proc // capture loop variable
 		cmds = append(cmds, func() tea.Msg {
-			waitForDependencies(m.processes, p.Config.DependsOn)
-			if err := p.Start(); err != nil {
-				// We could send an error msg, but status is handled in p.Status
-			}
+			// Failures are reflected in the process state
+			_ = p.StartAfter(m.tasks)
 			return nil
 		})
 	}
@@ -172,13 +196,73 @@ func (m Model) Init() tea.Cmd {
 	return tea.Batch(tea.Batch(cmds...), tea.EnableMouseCellMotion)
 }
 
-func waitForActivity(name string, output chan string) tea.Cmd {
+// waitForEvent delivers the next lifecycle event of p as a StateChangedMsg,
+// or nothing once p is closed.
+func waitForEvent(p *process.Process) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case event := <-p.Events:
+			return StateChangedMsg{Event: event, proc: p}
+		case <-p.Done():
+			return nil
+		}
+	}
+}
+
+// Log rendering limits: a process produces at most one LogMsg per frame,
+// carrying every line that arrived in the meantime.
+const (
+	logFrameInterval = time.Second / 30
+	maxLogBatch      = 5000
+)
+
+// waitForActivity blocks until the process prints, waits one frame for the
+// burst to accumulate and returns everything available as a single LogMsg.
+// It returns nothing once p is closed.
+func waitForActivity(p *process.Process) tea.Cmd {
 	return func() tea.Msg {
-		line, ok := <-output
-		if !ok {
+		var line process.LogLine
+		select {
+		case line = <-p.Output:
+		case <-p.Done():
 			return nil
 		}
-		return LogMsg{ProcessName: name, Content: line}
+		time.Sleep(logFrameInterval)
+
+		lines := []process.LogLine{line}
+		for len(lines) < maxLogBatch {
+			select {
+			case line := <-p.Output:
+				lines = append(lines, line)
+			default:
+				return LogMsg{ProcessName: p.Config.Name, Lines: lines, proc: p}
+			}
+		}
+		return LogMsg{ProcessName: p.Config.Name, Lines: lines, proc: p}
+	}
+}
+
+// startProcess starts p once its dependencies among tasks are ready.
+func startProcess(p *process.Process, tasks *process.Registry) tea.Cmd {
+	return func() tea.Msg {
+		_ = p.StartAfter(tasks)
+		return nil
+	}
+}
+
+// stopProcess stops p off the UI loop, Stop blocks until it exited.
+func stopProcess(p *process.Process) tea.Cmd {
+	return func() tea.Msg {
+		_ = p.Stop()
+		return nil
+	}
+}
+
+// restartProcess restarts p off the UI loop, since a graceful stop can block.
+func restartProcess(p *process.Process) tea.Cmd {
+	return func() tea.Msg {
+		_ = p.Restart()
+		return nil
 	}
 }
 
@@ -203,10 +287,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Schedule next tick
 		return m, func() tea.Msg { return fetchSystemStats() }
 
+	case ConfigErrorMsg:
+		m.configErr = msg.Err
+
 	case ConfigChangedMsg:
 		newCfg := msg
 		m.theme = newCfg.Theme
-		var newProcs []*process.Process
+		m.startOrder = (*config.Config)(newCfg).StartOrder()
+		m.configErr = nil
+		var newProcs, added []*process.Process
 
 		// Map existing processes by name for easy lookup
 		existing := make(map[string]*process.Process)
@@ -216,37 +305,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		for _, task := range newCfg.Tasks {
 			if proc, ok := existing[task.Name]; ok {
-				// Process exists, check if config changed
-				// Simple comparison: check command, dir, env
-				// We can use reflect.DeepEqual if we import reflect, or manual check.
-				// Manual check for key fields is safer/faster.
-				changed := proc.Config.Command != task.Command ||
-					proc.Config.Directory != task.Directory ||
-					len(proc.Config.Env) != len(task.Env) // Superficial env check
-
-				if !changed {
-					// Check Env deeply
-					for i, e := range proc.Config.Env {
-						if e != task.Env[i] {
-							changed = true
-							break
-						}
-					}
-				}
-
-				if changed {
+				// Process exists, replace it if it would run differently
+				if !sameProcess(proc.Config, task) {
 					// Config changed, restart with new config
-					_ = proc.Stop()
 					// Create new process instance to ensure clean state
 					newProc := process.NewProcess(task)
-					_ = newProc.Start()
+					oldProc := proc
+					// unless-stopped tasks stay down if the user stopped them
+					keepStopped := oldProc.StoppedByUser() && task.Restart == config.RestartUnlessStopped
+					// Tasks that were never started stay that way
+					keepStopped = keepStopped || oldProc.State() == process.StatePending
+					cmds = append(cmds, func() tea.Msg {
+						// Graceful stop may block, so do it off the UI loop
+						_ = oldProc.Stop()
+						oldProc.Close()
+						if !keepStopped {
+							_ = newProc.StartAfter(m.tasks)
+						}
+						return nil
+					})
 					newProcs = append(newProcs, newProc)
 					// We need to re-hook the activity listener?
 					// Yes, Init() called Start() and waitForActivity.
 					// We need to spawn waitForActivity for the new process.
-					cmds = append(cmds, waitForActivity(newProc.Config.Name, newProc.Output))
+					cmds = append(cmds, waitForActivity(newProc))
+					cmds = append(cmds, waitForEvent(newProc))
 				} else {
-					// Keep existing process
+					// Keep existing process, taking over the settings only the UI reads
+					proc.Config.Groups = task.Groups
+					proc.Config.Autostart = task.Autostart
+					proc.Config.RestartDependents = task.RestartDependents
 					newProcs = append(newProcs, proc)
 					// We need to ensure the index in waitForActivity matches?
 					// waitForActivity captures 'index'. If index changes (reorder), LogMsg will have old index.
@@ -262,11 +350,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					// Or just use Name.
 				}
 			} else {
-				// New process
+				// New process, started once its dependencies are ready
 				newProc := process.NewProcess(task)
-				if err := newProc.Start(); err == nil {
-					newProcs = append(newProcs, newProc)
-					cmds = append(cmds, waitForActivity(newProc.Config.Name, newProc.Output))
+				newProcs = append(newProcs, newProc)
+				cmds = append(cmds, waitForActivity(newProc))
+				cmds = append(cmds, waitForEvent(newProc))
+				if task.ShouldAutostart() {
+					added = append(added, newProc)
 				}
 			}
 		}
@@ -284,13 +374,42 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			usedNames[p.Config.Name] = true
 		}
 
+		var removed []*process.Process
 		for name, p := range existing {
 			if !usedNames[name] {
-				_ = p.Stop()
+				removed = append(removed, p)
 			}
 		}
+		if len(removed) > 0 {
+			cmds = append(cmds, func() tea.Msg {
+				stopAll(removed)
+				for _, p := range removed {
+					p.Close()
+				}
+				return nil
+			})
+		}
 
 		m.processes = newProcs
+		m.tasks.Set(newProcs)
+		for _, p := range added {
+			cmds = append(cmds, func() tea.Msg {
+				_ = p.StartAfter(m.tasks)
+				return nil
+			})
+		}
+
+		// Detach if the attached task was replaced or removed
+		if m.attached != nil {
+			kept := false
+			for _, p := range m.processes {
+				kept = kept || p == m.attached.proc
+			}
+			if !kept {
+				m.attached.detach()
+				m.attached = nil
+			}
+		}
 
 		// Sort again
 		sort.SliceStable(m.processes, func(i, j int) bool {
@@ -305,6 +424,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return g1 < g2
 		})
 
+		m.groups = collectGroups(newCfg.Tasks)
+		if m.groupCursor >= len(m.groups) {
+			m.groupCursor = max(len(m.groups)-1, 0)
+		}
+
 		// Adjust cursor if out of bounds
 		if m.cursor >= len(m.processes) {
 			m.cursor = len(m.processes) - 1
@@ -313,11 +437,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		if len(m.processes) > 0 && !m.visible(m.cursor) && !m.moveCursor(1) && !m.moveCursor(-1) {
+			// No task of the focused group is left
+			m.focusGroup = ""
+		}
+
 		// Re-render viewport
+		m.merged = nil
 		if len(m.processes) > 0 {
-			m.viewport.SetContent(m.processes[m.cursor].LogBuffer)
+			m.viewport.SetProcess(m.processes[m.cursor])
 		} else {
-			m.viewport.SetContent("")
+			m.viewport.SetProcess(nil)
 		}
 
 	case tea.MouseMsg:
@@ -346,19 +476,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Inside list box: Line 0 is "DevDeck", Line 1 is empty. Line 2 is Task 0.
 				// So Task 0 is at global Y = 1 + 2 = 3.
 
-				clickedIndex := msg.Y - 4 // Approximate offset (1 global + 2 header + 1 border?)
-				// Let's allow clicking broadly.
+				row := msg.Y - 4 // Approximate offset (1 global + 2 header + 1 border?)
+				rows := m.listRows()
 
-				if clickedIndex >= 0 && clickedIndex < len(m.processes) {
-					m.cursor = clickedIndex
+				if row >= 0 && row < len(rows) && rows[row].header == "" {
+					m.cursor = rows[row].index
 					m.focusedPane = FocusList
 
 					// Update logs similar to 'down' key
-					proc := m.processes[m.cursor]
-					content, matches := highlightLogs(proc.LogBuffer, m.searchQuery)
-					m.viewport.SetContent(content)
-					m.matches = matches
-					m.viewport.GotoBottom()
+					m = m.showSelected()
 				}
 			} else {
 				// Click in Log Area
@@ -403,8 +529,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		availableHeight := msg.Height - 4 // General safe area
 
 		if !m.ready {
-			m.viewport = viewport.New(logWidth, availableHeight)
-			m.secondaryViewport = viewport.New(logWidth, availableHeight)
+			m.viewport = newLogView(logWidth, availableHeight)
+			m.secondaryViewport = newLogView(logWidth, availableHeight)
+			if len(m.processes) > 0 {
+				m.viewport.SetProcess(m.processes[m.cursor])
+			}
 			m.ready = true
 		} else {
 			m.viewport.Width = logWidth
@@ -440,6 +569,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case tea.KeyMsg:
+		// While attached every key except the detach chord belongs to the task
+		if m.attached != nil {
+			if msg.String() == detachKey {
+				m.attached.detach()
+				m.attached = nil
+				return m, nil
+			}
+			m.attached.sendKey(msg)
+			m.viewport.GotoBottom()
+			return m, nil
+		}
+
 		// Toggle Help
 		if msg.String() == "?" {
 			m.helpVisible = !m.helpVisible
@@ -454,6 +595,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// If Detail panel is visible, any of these keys close it
+		if m.detailVisible {
+			switch msg.String() {
+			case "esc", "q", "enter":
+				m.detailVisible = false
+			}
+			return m, nil
+		}
+
 		// If Group Menu is visible
 		if m.groupMenuVisible {
 			switch msg.String() {
@@ -468,22 +618,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.groupCursor < len(m.groups)-1 {
 					m.groupCursor++
 				}
-			case "enter":
-				// Restart Group
+			case "enter", "r", "u", "x":
+				if len(m.groups) > 0 {
+					cmds = append(cmds, m.groupAction(msg.String(), m.groups[m.groupCursor]))
+					m.groupMenuVisible = false
+				}
+			case "l":
+				// Merged logs of the group
 				if len(m.groups) > 0 {
-					targetGroup := m.groups[m.groupCursor]
-					for _, p := range m.processes {
-						for _, g := range p.Config.Groups {
-							if g == targetGroup {
-								_ = p.Restart()
-								p.LogBuffer += fmt.Sprintf("\n--- GROUP RESTART (%s) ---\n", targetGroup)
-							}
+					m.merged = m.newMergedLog(m.groups[m.groupCursor])
+					m.viewport.SetBuffer(m.merged.buffer)
+					m.matches = findMatches(m.merged.buffer, m.searchQuery, m.viewport.filter)
+					m.groupMenuVisible = false
+				}
+			case "f":
+				// List only the group's tasks, again to list all
+				if len(m.groups) > 0 {
+					group := m.groups[m.groupCursor]
+					if m.focusGroup == group {
+						m.focusGroup = ""
+					} else {
+						m.focusGroup = group
+						if !m.visible(m.cursor) && !m.moveCursor(1) {
+							m.moveCursor(-1)
 						}
+						m = m.showSelected()
 					}
 					m.groupMenuVisible = false
 				}
 			}
-			return m, nil
+			return m, tea.Batch(cmds...)
 		}
 
 		switch msg.String() {
@@ -501,6 +665,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, textinput.Blink
 			}
 
+		case "a":
+			if m.inputMode == InputNone && len(m.processes) > 0 {
+				proc := m.processes[m.cursor]
+				if proc.State().Alive() {
+					m.attached = attach(proc)
+					m.focusedPane = FocusLog
+					m.viewport.GotoBottom()
+				}
+			}
+
 		case "/":
 			if m.inputMode == InputNone {
 				m.inputMode = InputSearch
@@ -511,10 +685,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "enter":
 			if m.inputMode == InputProcess {
-				val := m.textInput.Value()
 				// Send input to the currently selected process
-				proc := m.processes[m.cursor]
-				_ = proc.SendInput(val)
+				if len(m.processes) > 0 {
+					_ = m.processes[m.cursor].SendInput(m.textInput.Value())
+				}
 
 				// Reset input
 				m.textInput.SetValue("")
@@ -524,17 +698,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				val := m.textInput.Value()
 				m.searchQuery = val
 
-				// Update viewport with filtered content
-				proc := m.processes[m.cursor]
-				content, matches := highlightLogs(proc.LogBuffer, m.searchQuery)
-				m.viewport.SetContent(content)
-				m.matches = matches
+				// Update viewport with highlighted content
+				m.viewport.SetQuery(m.searchQuery)
+				m.matches = findMatches(m.currentLogs(), m.searchQuery, m.viewport.filter)
 				m.viewport.GotoBottom()
 
 				// Reset input
 				m.textInput.SetValue("")
 				m.textInput.Blur()
 				m.inputMode = InputNone
+			} else if m.focusedPane == FocusList && len(m.processes) > 0 {
+				// Show task details
+				m.detailVisible = true
 			}
 
 		case "esc":
@@ -545,9 +720,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else if m.searchQuery != "" {
 				// Clear search query
 				m.searchQuery = ""
-				proc := m.processes[m.cursor]
-				m.viewport.SetContent(proc.LogBuffer)
+				m.matches = []uint64{}
+				m.viewport.SetQuery("")
 				m.viewport.GotoBottom()
+			} else if m.merged != nil {
+				m = m.showSelected()
+			} else if m.focusGroup != "" {
+				m.focusGroup = ""
+			}
+
+		case "f":
+			if m.inputMode == InputNone {
+				// Cycle the streams shown in the focused log pane
+				if m.focusedPane == FocusSecondary {
+					m.secondaryViewport.SetFilter(m.secondaryViewport.filter.next())
+				} else if len(m.processes) > 0 {
+					m.viewport.SetFilter(m.viewport.filter.next())
+					m.matches = findMatches(m.currentLogs(), m.searchQuery, m.viewport.filter)
+				}
 			}
 
 		case "tab":
@@ -565,59 +755,88 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "ctrl+c", "q":
-			if m.inputMode == InputNone {
-				for _, p := range m.processes {
-					_ = p.Stop()
+			if m.quitting && msg.String() == "ctrl+c" {
+				// Second ctrl+c: don't wait for graceful shutdown
+				procs := m.processes
+				return m, func() tea.Msg {
+					killAll(procs)
+					for _, p := range procs {
+						p.Close()
+					}
+					return tea.Quit()
+				}
+			}
+			if m.inputMode == InputNone && !m.quitting {
+				m.quitting = true
+				procs := m.processes
+				return m, func() tea.Msg {
+					stopAll(procs)
+					for _, p := range procs {
+						p.Close()
+					}
+					return tea.Quit()
 				}
-				return m, tea.Quit
 			}
 
 		case "up", "k":
 			if m.inputMode == InputNone && m.focusedPane == FocusList {
-				if m.cursor > 0 {
-					m.cursor--
-					content, _ := highlightLogs(m.processes[m.cursor].LogBuffer, m.searchQuery)
-					m.viewport.SetContent(content)
-					m.viewport.GotoBottom()
+				if m.moveCursor(-1) {
+					m = m.showSelected()
 				}
 			}
 		case "down", "j":
 			if m.inputMode == InputNone && m.focusedPane == FocusList {
-				if m.cursor < len(m.processes)-1 {
-					m.cursor++
-					content, _ := highlightLogs(m.processes[m.cursor].LogBuffer, m.searchQuery)
-					m.viewport.SetContent(content)
-					m.viewport.GotoBottom()
+				if m.moveCursor(1) {
+					m = m.showSelected()
 				}
 			}
-		case "r":
-			if m.inputMode == InputNone {
+		case "r", "R":
+			if m.inputMode == InputNone && len(m.processes) > 0 {
 				proc := m.processes[m.cursor]
-				_ = proc.Restart()
-				proc.LogBuffer += "\n--- RESTARTED ---\n"
-
-				atBottom := m.viewport.AtBottom()
-				content, matches := highlightLogs(proc.LogBuffer, m.searchQuery)
-				m.viewport.SetContent(content)
-				m.matches = matches
-				if atBottom {
-					m.viewport.GotoBottom()
+				if msg.String() == "R" || proc.Config.RestartDependents {
+					cascade := restartable(m.withDependents(proc))
+					cmds = append(cmds, restartInOrder(cascade, m.tasks))
+					for _, p := range cascade {
+						p.Logs.Append(process.LogLine{Stream: process.StreamSystem, Text: "--- RESTARTED ---"})
+					}
+				} else {
+					cmds = append(cmds, restartProcess(proc))
+					proc.Logs.Append(process.LogLine{Stream: process.StreamSystem, Text: "--- RESTARTED ---"})
 				}
-
-				if m.cursor == m.pinnedIndex {
-					atBottomSec := m.secondaryViewport.AtBottom()
-					m.secondaryViewport.SetContent(proc.LogBuffer)
-					if atBottomSec {
-						m.secondaryViewport.GotoBottom()
+			}
+		case "u", "x", " ":
+			if m.inputMode == InputNone && m.focusedPane == FocusList && len(m.processes) > 0 {
+				proc := m.processes[m.cursor]
+				state := proc.State()
+				running := state.Alive() || state == process.StateBlocked || state == process.StateBackoff
+				if msg.String() == "x" || msg.String() == " " && running {
+					cmds = append(cmds, stopProcess(proc))
+				} else if !state.Alive() {
+					cmds = append(cmds, startProcess(proc, m.tasks))
+				}
+			}
+		case "U":
+			if m.inputMode == InputNone {
+				for _, p := range m.inStartOrder() {
+					if !p.State().Alive() {
+						cmds = append(cmds, startProcess(p, m.tasks))
 					}
 				}
 			}
+		case "X":
+			if m.inputMode == InputNone {
+				procs := m.processes
+				cmds = append(cmds, func() tea.Msg {
+					stopAll(procs)
+					return nil
+				})
+			}
 		case "s":
 			if m.inputMode == InputNone {
 				if m.pinnedIndex == -1 {
 					// Enable Split View
 					m.pinnedIndex = m.cursor
-					m.secondaryViewport.SetContent(m.processes[m.pinnedIndex].LogBuffer)
+					m.secondaryViewport.SetProcess(m.processes[m.pinnedIndex])
 
 					// Resize viewports for split
 					if m.height > 0 {
@@ -663,6 +882,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case StateChangedMsg:
+		// Keep listening unless the process was removed by a hot reload
+		for _, p := range m.processes {
+			if p == msg.proc {
+				cmds = append(cmds, waitForEvent(p))
+				break
+			}
+		}
+
+		// Nothing left to talk to once the attached task exits
+		if m.attached != nil && m.attached.proc == msg.proc && !msg.To.Alive() {
+			m.attached.detach()
+			m.attached = nil
+		}
+
 	case LogMsg:
 		// Find process by name
 		var proc *process.Process
@@ -675,34 +909,37 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		if proc == nil {
-			// Process might have been removed during hot reload
+		if proc == nil || msg.proc != nil && msg.proc != proc {
+			// Process might have been removed or replaced during hot reload
 			return m, nil
 		}
 
-		proc.LogBuffer += msg.Content + "\n"
-
-		if index == m.cursor {
-			atBottom := m.viewport.AtBottom()
-			// Apply filter if search query exists
-			content, matches := highlightLogs(proc.LogBuffer, m.searchQuery)
-			m.viewport.SetContent(content)
-			m.matches = matches
-			if atBottom {
-				m.viewport.GotoBottom()
+		// Views read the visible window straight from the buffer
+		shown := index == m.cursor
+		if m.merged != nil {
+			shown = inGroup(proc, m.merged.group)
+		}
+		for _, line := range msg.Lines {
+			line = proc.Logs.Append(line)
+			if m.merged != nil && shown {
+				line = m.merged.buffer.Append(tagLine(proc, line))
+			}
+			if shown && m.viewport.filter.match(line) && containsFold(line.Text, m.searchQuery) {
+				m.matches = append(m.matches, line.Seq)
 			}
 		}
 
-		if index == m.pinnedIndex {
-			atBottom := m.secondaryViewport.AtBottom()
-			// Pinned view always shows full logs (design choice for now)
-			m.secondaryViewport.SetContent(proc.LogBuffer)
-			if atBottom {
-				m.secondaryViewport.GotoBottom()
+		// Forget matches that were evicted from the buffer
+		if shown && len(m.matches) > 0 {
+			first := m.currentLogs().FirstSeq()
+			i := 0
+			for i < len(m.matches) && m.matches[i] < first {
+				i++
 			}
+			m.matches = m.matches[i:]
 		}
 
-		cmds = append(cmds, waitForActivity(msg.ProcessName, proc.Output))
+		cmds = append(cmds, waitForActivity(proc))
 	}
 
 	if m.focusedPane == FocusLog {
@@ -720,100 +957,60 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 	}
 
+	if m.ready {
+		// Panes may have been resized, split or switched to another task
+		m.resizeTerminals()
+	}
+
 	return m, tea.Batch(cmds...)
 }
 
-func highlightLogs(buffer string, query string) (string, []int) {
-	if query == "" {
-		return buffer, []int{}
+// showSelected shows the logs of the task under the cursor in the main pane.
+func (m Model) showSelected() Model {
+	m.merged = nil
+	if len(m.processes) == 0 {
+		return m
 	}
+	m.viewport.SetProcess(m.processes[m.cursor])
+	m.matches = findMatches(m.currentLogs(), m.searchQuery, m.viewport.filter)
+	return m
+}
 
-	lines := strings.Split(buffer, "\n")
-	var matches []int
-	var highlighted strings.Builder
-
-	// Prepare highlight style (classic yellow background, black text)
-	hlStyle := lipgloss.NewStyle().Background(lipgloss.Color("#FFFF00")).Foreground(lipgloss.Color("#000000"))
-
-	lowQuery := strings.ToLower(query)
-
-	for i, line := range lines {
-		if strings.Contains(strings.ToLower(line), lowQuery) {
-			matches = append(matches, i)
-
-			var sb strings.Builder
-			currentLower := strings.ToLower(line)
-			currentOriginal := line
-
-			for {
-				idx := strings.Index(currentLower, lowQuery)
-				if idx == -1 {
-					sb.WriteString(currentOriginal)
-					break
-				}
-
-				sb.WriteString(currentOriginal[:idx])
-				sb.WriteString(hlStyle.Render(currentOriginal[idx : idx+len(query)]))
+// currentLogs returns the buffer shown in the main pane, nil if there are no
+// tasks.
+func (m Model) currentLogs() *process.LogBuffer {
+	if m.merged != nil {
+		return m.merged.buffer
+	}
+	if len(m.processes) == 0 {
+		return nil
+	}
+	return m.processes[m.cursor].Logs
+}
 
-				currentLower = currentLower[idx+len(query):]
-				currentOriginal = currentOriginal[idx+len(query):]
-			}
-			highlighted.WriteString(sb.String() + "\n")
+// resizeTerminals matches the terminal size of tty tasks to the pane showing
+// them: the pinned pane for the pinned task, the main pane for all others.
+func (m Model) resizeTerminals() {
+	for i, p := range m.processes {
+		if i == m.pinnedIndex && i != m.cursor {
+			p.Resize(m.secondaryViewport.Width, m.secondaryViewport.Height)
 		} else {
-			highlighted.WriteString(line + "\n")
+			p.Resize(m.viewport.Width, m.viewport.Height)
 		}
 	}
-	return highlighted.String(), matches
 }
 
-// waitForDependencies blocks until all named dependencies are healthy/running
-func waitForDependencies(processes []*process.Process, dependsOn []string) {
-	if len(dependsOn) == 0 {
-		return
+// findMatches returns the sequence numbers of the lines passing filter that
+// contain query.
+func findMatches(buffer *process.LogBuffer, query string, filter streamFilter) []uint64 {
+	matches := []uint64{}
+	if query == "" || buffer == nil {
+		return matches
 	}
-
-	for {
-		allReady := true
-		for _, depName := range dependsOn {
-			// Find dependency process
-			var dep *process.Process
-			for _, p := range processes {
-				if p.Config.Name == depName {
-					dep = p
-					break
-				}
-			}
-
-			if dep == nil {
-				// Dependency not found? strict or loose?
-				// Treat as ready to avoid deadlock for typos, maybe log error?
-				// For now, assume ready.
-				continue
-			}
-
-			// Check status
-			// If it has a health check, wait for "Healthy"
-			// If not, wait for "Running"
-			isReady := false
-			if dep.Config.HealthCheck != nil {
-				if dep.HealthStatus == "Healthy" {
-					isReady = true
-				}
-			} else {
-				if dep.Status == "Running" {
-					isReady = true
-				}
-			}
-
-			if !isReady {
-				allReady = false
-				break
-			}
-		}
-
-		if allReady {
-			return
+	for _, line := range buffer.All() {
+		if filter.match(line) && containsFold(line.Text, query) {
+			matches = append(matches, line.Seq)
 		}
-		time.Sleep(500 * time.Millisecond)
 	}
+	return matches
 }
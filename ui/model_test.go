@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kuo-hm/devdeck/config"
+	"github.com/kuo-hm/devdeck/process"
+)
+
+// logModel returns a ready model with one task that has history lines of
+// output.
+func logModel(history int) Model {
+	cfg := &config.Config{Tasks: []config.Task{{Name: "app", Command: "app", MaxLogLines: 200000}}}
+	model, _ := InitialModel(cfg).Update(tea.WindowSizeMsg{Width: 160, Height: 50})
+	m := model.(Model)
+	for sent := 0; sent < history; sent += maxLogBatch {
+		model, _ = m.Update(LogMsg{ProcessName: "app", Lines: logLines(sent, maxLogBatch)})
+		m = model.(Model)
+	}
+	return m
+}
+
+func logLines(from, n int) []process.LogLine {
+	lines := make([]process.LogLine, n)
+	for i := range lines {
+		lines[i] = process.LogLine{
+			Time:   time.Now(),
+			Stream: process.Stream(i % 2),
+			Text:   fmt.Sprintf("line %d: GET /api/items?page=%d 200 12ms", from+i, i),
+		}
+	}
+	return lines
+}
+
+// frameCost returns the fastest of several frames, each delivering a batch
+// of lines and rendering the view.
+func frameCost(m Model) time.Duration {
+	batch := logLines(0, 100)
+	best := time.Duration(1<<63 - 1)
+	for i := 0; i < 20; i++ {
+		start := time.Now()
+		model, _ := m.Update(LogMsg{ProcessName: "app", Lines: batch})
+		_ = model.(Model).View()
+		if d := time.Since(start); d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// BenchmarkLogMsg feeds 100k lines through Update and renders them. The cost
+// of a frame must not depend on how much history the task has.
+func BenchmarkLogMsg(b *testing.B) {
+	small, large := logModel(10000), logModel(100000)
+	if n := large.processes[0].Logs.Len(); n != 100000 {
+		b.Fatalf("history = %d lines, want 100000", n)
+	}
+
+	smallCost, largeCost := frameCost(small), frameCost(large)
+	if largeCost > 3*smallCost {
+		b.Fatalf("frame at 100k lines took %s, at 10k lines %s", largeCost, smallCost)
+	}
+
+	batch := logLines(0, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		model, _ := large.Update(LogMsg{ProcessName: "app", Lines: batch})
+		large = model.(Model)
+		_ = large.View()
+	}
+}
+
+func TestReloadReplacesChangedTask(t *testing.T) {
+	base := config.Task{Name: "app", Command: "app", Env: []string{"PORT=8080"}}
+	tests := []struct {
+		name     string
+		edit     func(*config.Task)
+		replaced bool
+	}{
+		{"unchanged", func(*config.Task) {}, false},
+		{"command", func(t *config.Task) { t.Command = "app --debug" }, true},
+		{"env", func(t *config.Task) { t.Env = []string{"PORT=9090"} }, true},
+		{"health check", func(t *config.Task) {
+			t.HealthCheck = &config.HealthCheck{Type: config.HealthTCP, Target: "localhost:8080"}
+		}, true},
+		{"restart policy", func(t *config.Task) { t.Restart = config.RestartAlways }, true},
+		{"stop signal", func(t *config.Task) { t.StopSignal = "SIGINT" }, true},
+		{"depends on", func(t *config.Task) { t.DependsOn = config.Dependencies{{Name: "db"}} }, true},
+		{"log overflow", func(t *config.Task) { t.LogOverflow = config.OverflowSpill }, true},
+		{"groups", func(t *config.Task) { t.Groups = []string{"backend"} }, false},
+		{"restart dependents", func(t *config.Task) { t.RestartDependents = true }, false},
+		{"autostart", func(t *config.Task) {
+			autostart := false
+			t.Autostart = &autostart
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := InitialModel(&config.Config{Tasks: []config.Task{base}})
+			old := m.processes[0]
+
+			task := base
+			task.Env = append([]string(nil), base.Env...)
+			tt.edit(&task)
+			model, _ := m.Update(ConfigChangedMsg(&config.Config{Tasks: []config.Task{task}}))
+			proc := model.(Model).processes[0]
+			if replaced := proc != old; replaced != tt.replaced {
+				t.Errorf("replaced = %v, want %v", replaced, tt.replaced)
+			}
+			if !reflect.DeepEqual(proc.Config, task) {
+				t.Errorf("task = %+v, want %+v", proc.Config, task)
+			}
+		})
+	}
+}
+
+func TestClickSelectsListedTask(t *testing.T) {
+	cfg := &config.Config{Tasks: []config.Task{
+		{Name: "web", Command: "web"},
+		{Name: "api", Command: "api", Groups: []string{"backend"}},
+		{Name: "db", Command: "db", Groups: []string{"backend"}},
+		{Name: "cli", Command: "cli", Groups: []string{"tools"}},
+	}}
+	tests := []struct {
+		name       string
+		focusGroup string
+		y          int
+		want       string
+	}{
+		{"first task", "", 4, "web"},
+		{"group header", "", 5, "db"},
+		{"below header", "", 6, "api"},
+		{"second group", "", 9, "cli"},
+		{"below list", "", 10, "db"},
+		{"focused group", "tools", 5, "cli"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, _ := InitialModel(cfg).Update(tea.WindowSizeMsg{Width: 160, Height: 50})
+			m := model.(Model)
+			m.focusGroup = tt.focusGroup
+			for i, p := range m.processes {
+				if p.Config.Name == "db" {
+					m.cursor = i
+				}
+			}
+
+			model, _ = m.Update(tea.MouseMsg{X: 5, Y: tt.y, Type: tea.MouseLeft})
+			m = model.(Model)
+			if got := m.processes[m.cursor].Config.Name; got != tt.want {
+				t.Errorf("selected %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReloadCollectsGroups(t *testing.T) {
+	m := InitialModel(&config.Config{Tasks: []config.Task{
+		{Name: "api", Command: "api", Groups: []string{"backend"}},
+	}})
+	model, _ := m.Update(ConfigChangedMsg(&config.Config{Tasks: []config.Task{
+		{Name: "api", Command: "api", Groups: []string{"backend"}},
+		{Name: "cli", Command: "cli", Groups: []string{"tools", "backend"}},
+	}}))
+	got := model.(Model).groups
+	if fmt.Sprint(got) != fmt.Sprint([]string{"backend", "tools"}) {
+		t.Errorf("groups = %v, want [backend tools]", got)
+	}
+}
+
+func TestKeysWithoutTasks(t *testing.T) {
+	keys := []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("r")},
+		{Type: tea.KeyRunes, Runes: []rune("R")},
+		{Type: tea.KeyRunes, Runes: []rune("u")},
+		{Type: tea.KeyRunes, Runes: []rune("x")},
+		{Type: tea.KeySpace, Runes: []rune(" ")},
+		{Type: tea.KeyRunes, Runes: []rune("a")},
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyRunes, Runes: []rune("i")},
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyRunes, Runes: []rune("/")},
+		{Type: tea.KeyRunes, Runes: []rune("err")},
+		{Type: tea.KeyEnter},
+	}
+	model, _ := InitialModel(&config.Config{}).Update(tea.WindowSizeMsg{Width: 160, Height: 50})
+	for _, key := range keys {
+		model, _ = model.Update(key)
+		_ = model.View()
+	}
+	if mode := model.(Model).inputMode; mode != InputNone {
+		t.Errorf("input mode = %d after enter, want %d", mode, InputNone)
+	}
+}
+
+func TestListenersStopOnClose(t *testing.T) {
+	p := process.NewProcess(config.Task{Name: "app", Command: "app"})
+	p.Close()
+
+	for name, cmd := range map[string]tea.Cmd{"waitForActivity": waitForActivity(p), "waitForEvent": waitForEvent(p)} {
+		msgs := make(chan tea.Msg, 1)
+		go func() { msgs <- cmd() }()
+		select {
+		case msg := <-msgs:
+			if msg != nil {
+				t.Errorf("%s returned %#v for a closed task", name, msg)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s still waiting after Close", name)
+		}
+	}
+}
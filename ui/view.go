@@ -3,9 +3,11 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kuo-hm/devdeck/config"
+	"github.com/kuo-hm/devdeck/process"
 )
 
 func getThemeColor(theme *config.Theme, key, fallback string) lipgloss.Color {
@@ -29,6 +31,10 @@ func getThemeColor(theme *config.Theme, key, fallback string) lipgloss.Color {
 		if theme.Text != "" {
 			return lipgloss.Color(theme.Text)
 		}
+	case "stderr":
+		if theme.Stderr != "" {
+			return lipgloss.Color(theme.Stderr)
+		}
 	}
 	return lipgloss.Color(fallback)
 }
@@ -43,6 +49,7 @@ func (m Model) View() string {
 	secondary := getThemeColor(m.theme, "secondary", "#7D56F4") // Purple
 	border := getThemeColor(m.theme, "border", "63")            // Dim Purple
 	text := getThemeColor(m.theme, "text", "240")               // Grey
+	stderr := getThemeColor(m.theme, "stderr", "#FF5F5F")       // Red
 
 	focusedStyle := lipgloss.NewStyle().Foreground(primary)
 	normalStyle := lipgloss.NewStyle().Foreground(text)
@@ -54,41 +61,32 @@ func (m Model) View() string {
 	var tasksView strings.Builder
 	tasksView.WriteString(titleStyle.Render("DevDeck") + "\n\n")
 
-	lastGroup := ""
-	for i, proc := range m.processes {
-		// Determine group for this process
-		thisGroup := ""
-		if len(proc.Config.Groups) > 0 {
-			thisGroup = proc.Config.Groups[0]
+	for _, row := range m.listRows() {
+		// User wanted "---group1", styled a bit dimmed
+		if row.header != "" {
+			header := fmt.Sprintf("--- %s", row.header)
+			tasksView.WriteString(lipgloss.NewStyle().Foreground(secondary).Render(header) + "\n")
+			continue
 		}
-
-		// Render Group Header if changed and not empty
-		if thisGroup != "" {
-			if i == 0 || lastGroup != thisGroup {
-				// Add spacing if not first line?
-				// User wanted "---group1".
-				// Let's style it bit dimmed.
-				header := fmt.Sprintf("--- %s", thisGroup)
-				tasksView.WriteString(lipgloss.NewStyle().Foreground(secondary).Render(header) + "\n")
-			}
-		}
-		lastGroup = thisGroup
+		i, proc := row.index, m.processes[row.index]
 
 		cursor := " "
 		if m.cursor == i {
 			cursor = ">"
 		}
 
-		status := "🔴"
-		if proc.Status == "Running" {
-			if proc.HealthStatus == "Healthy" {
-				status = "💚"
-			} else if proc.HealthStatus == "Unhealthy" {
-				status = "💔"
-			} else if proc.HealthStatus == "Starting" {
-				status = "🟡"
-			} else {
-				status = "🟢" // Running but no health check or unchecked
+		state := proc.State()
+		status := stateIcon(state)
+		if state == process.StateExited && proc.StoppedByUser() {
+			status = "⚫"
+		}
+		oneshot := proc.Config.Type == config.TaskOneshot
+		if oneshot {
+			switch {
+			case proc.Completed():
+				status = "✅"
+			case state == process.StateFailed:
+				status = "❌"
 			}
 		}
 
@@ -99,16 +97,50 @@ func (m Model) View() string {
 
 		line := fmt.Sprintf("%s %s %s %s", cursor, pin, status, proc.Config.Name)
 
-		if proc.Status == "Running" {
-			mb := float64(proc.MemUsage) / 1024 / 1024
-			// Abbreviated stats: (0%, 36M)
-			line += fmt.Sprintf(" (%.0f%%, %.0fM)", proc.CPUUsage, mb)
+		switch state {
+		case process.StateStopping:
+			line += " (stopping...)"
+		case process.StateBackoff:
+			wait := time.Until(proc.NextRestart()).Round(time.Second)
+			if wait < 0 {
+				wait = 0
+			}
+			line += fmt.Sprintf(" (retry in %s)", wait)
+		case process.StateBlocked:
+			line += " (waiting for " + proc.BlockedOn() + ")"
+		default:
+			if state.Alive() {
+				cpuUsage, memUsage := proc.Stats()
+				mb := float64(memUsage) / 1024 / 1024
+				// Abbreviated stats: (0%, 36M)
+				line += fmt.Sprintf(" (%.0f%%, %.0fM)", cpuUsage, mb)
+			}
+		}
+
+		// How long the last run of a oneshot task took
+		if run, ok := proc.LastRun(); oneshot && ok && !state.Alive() {
+			line += " " + formatDuration(run.Uptime())
+		}
+
+		if restarts := proc.Restarts(); restarts > 0 {
+			line += fmt.Sprintf(" ↻%d", restarts)
+		}
+
+		if proc.Flapping() {
+			line += " ⚡"
+		}
+
+		// Say why the health check fails, details are in the Enter panel
+		if state == process.StateUnhealthy {
+			if reason := proc.HealthDetail(); reason != "" {
+				line += fmt.Sprintf(" (%s)", truncate(reason, 30))
+			}
 		}
 
 		// Inline group tag removed as requested by new visual style
 
-		if proc.Err != nil {
-			line += fmt.Sprintf(" (Err: %v)", proc.Err)
+		if err := proc.Err(); err != nil {
+			line += fmt.Sprintf(" (Err: %v)", err)
 		}
 
 		if m.cursor == i {
@@ -118,7 +150,7 @@ func (m Model) View() string {
 		}
 	}
 
-	tasksView.WriteString("\n'r': restart\n's': split view\n'i': input\n'/': search\n'?': help\n'q': quit\n")
+	tasksView.WriteString("\n'r': restart\n'u'/'x': start/stop\n's': split view\n'i': input\n'a': attach\n'/': search\n'?': help\n'q': quit\n")
 
 	// Determine border colors based on focus
 	listBorderColor := border
@@ -127,7 +159,9 @@ func (m Model) View() string {
 	}
 
 	logBorderColor := border
-	if m.focusedPane == FocusLog {
+	if m.attached != nil {
+		logBorderColor = secondary
+	} else if m.focusedPane == FocusLog {
 		logBorderColor = primary
 	}
 
@@ -161,6 +195,8 @@ func (m Model) View() string {
 
 	// Render the logs
 	var logPane string
+	m.viewport.stderrStyle = lipgloss.NewStyle().Foreground(stderr)
+	m.secondaryViewport.stderrStyle = m.viewport.stderrStyle
 
 	// The log viewports are already resized in Update() to the correct width/height.
 	// We just need to wrap them in a border.
@@ -224,6 +260,24 @@ func (m Model) View() string {
 		Padding(0, 1)
 
 	statusText := fmt.Sprintf("CPU: %.1f%% | MEM: %.1f%%", m.cpuUsage, m.memUsage)
+	if m.attached != nil {
+		statusText += " | ATTACHED to " + m.attached.proc.Config.Name + " (" + detachKey + " to detach)"
+	}
+	if m.viewport.filter != filterAll {
+		statusText += " | Showing " + m.viewport.filter.String() + " only"
+	}
+	if m.focusGroup != "" {
+		statusText += " | Group: " + m.focusGroup
+	}
+	if m.merged != nil {
+		statusText += " | Merged logs of " + m.merged.group + " (esc to close)"
+	}
+	if m.configErr != nil {
+		statusText += " | Config not reloaded: " + truncate(m.configErr.Error(), 80)
+	}
+	if m.quitting {
+		statusText += " | Stopping tasks... (ctrl+c again to force quit)"
+	}
 	statusBar := statusBarStyle.Render(statusText)
 
 	// Combine List + LogPane
@@ -244,12 +298,18 @@ func (m Model) View() string {
 					"  ↑/k, ↓/j   : Move cursor\n" +
 					"  Tab        : Switch focus\n\n" +
 					"Actions\n" +
-					"  Enter      : Select / Input\n" +
+					"  Enter      : Details / Send input\n" +
 					"  r          : Restart process\n" +
-					"  G          : Restart Group\n" +
+					"  R          : Restart with dependents\n" +
+					"  u / x      : Start / Stop process\n" +
+					"  Space      : Toggle process\n" +
+					"  U / X      : Start / Stop all\n" +
+					"  g          : Group menu\n" +
 					"  s          : Split/Pin view\n" +
 					"  i          : Interact (Stdin)\n" +
-					"  /          : Search logs\n\n" +
+					"  a          : Attach (ctrl+] detaches)\n" +
+					"  /          : Search logs\n" +
+					"  f          : Filter stdout/stderr\n\n" +
 					"General\n" +
 					"  ?          : Close Help\n" +
 					"  q/Esc      : Quit / Back",
@@ -262,17 +322,33 @@ func (m Model) View() string {
 			Render(helpBox)
 	}
 
+	if m.detailVisible && len(m.processes) > 0 {
+		detailBox := lipgloss.NewStyle().
+			Width(70).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(primary).
+			Padding(1, 2).
+			Render(titleStyle.Render("Task Details") + "\n\n" + renderDetails(m.processes[m.cursor]))
+
+		return lipgloss.NewStyle().Padding(2).Render(detailBox)
+	}
+
 	if m.groupMenuVisible {
 		var content strings.Builder
-		content.WriteString(titleStyle.Render("Select Group to Restart") + "\n\n")
+		content.WriteString(titleStyle.Render("Groups") + "\n\n")
 
 		for i, g := range m.groups {
 			cursor := "  "
 			if m.groupCursor == i {
 				cursor = "> "
 			}
-			content.WriteString(fmt.Sprintf("%s%s\n", cursor, g))
+			line := fmt.Sprintf("%s%s (%d)", cursor, g, len(m.groupMembers(g)))
+			if g == m.focusGroup {
+				line += " *"
+			}
+			content.WriteString(line + "\n")
 		}
+		content.WriteString("\nr/Enter: restart  u: start  x: stop\nl: merged logs  f: focus\n")
 
 		groupBox := lipgloss.NewStyle().
 			Width(40).
@@ -314,3 +390,191 @@ func (m Model) View() string {
 	// Re-compose final
 	return lipgloss.JoinVertical(lipgloss.Left, mainViewPadded, statusBar)
 }
+
+// stateIcon maps a lifecycle state to its list icon.
+func stateIcon(state process.State) string {
+	switch state {
+	case process.StateRunning:
+		return "🟢" // Running, no health check
+	case process.StateHealthy:
+		return "💚"
+	case process.StateUnhealthy:
+		return "💔"
+	case process.StateStarting:
+		return "🟡"
+	case process.StateStopping:
+		return "🟠"
+	case process.StateBackoff:
+		return "⏳"
+	case process.StateBlocked:
+		return "🔒"
+	case process.StateDependencyFailed:
+		return "⛔"
+	case process.StatePending:
+		return "⚪" // Not started yet, or autostart: false
+	default:
+		return "🔴"
+	}
+}
+
+// renderDetails lists the configuration and state of a single task.
+func renderDetails(proc *process.Process) string {
+	var b strings.Builder
+	row := func(label, value string) {
+		if value == "" {
+			value = "-"
+		}
+		b.WriteString(fmt.Sprintf("%-12s %s\n", label+":", value))
+	}
+
+	row("Name", proc.Config.Name)
+	if proc.Config.Type == config.TaskOneshot {
+		row("Type", config.TaskOneshot)
+	}
+	row("State", proc.State().String())
+
+	mode := proc.CommandMode()
+	switch mode {
+	case "shell":
+		mode += fmt.Sprintf(" (%s)", proc.Config.Shell)
+	case "split":
+		mode += " (whitespace)"
+	}
+	if proc.Config.TTY {
+		cols, rows := proc.Screen.Size()
+		mode += fmt.Sprintf(", tty %dx%d", cols, rows)
+	}
+	row("Mode", mode)
+	row("Command", strings.Join(proc.Argv(), " "))
+	row("Directory", proc.Config.Directory)
+
+	policy := proc.Config.Restart
+	if policy == "" {
+		policy = config.RestartNo
+	}
+	if proc.Config.MaxRestarts > 0 {
+		policy += fmt.Sprintf(" (max %d)", proc.Config.MaxRestarts)
+	}
+	row("Restart", policy)
+	row("Restarts", fmt.Sprintf("%d", proc.Restarts()))
+	if hc := proc.Config.HealthCheck; hc != nil && hc.OnUnhealthy == config.OnUnhealthyRestart {
+		row("Unhealthy", fmt.Sprintf("%d restarts", proc.UnhealthyRestarts()))
+	}
+	row("Groups", strings.Join(proc.Config.Groups, ", "))
+	deps := make([]string, len(proc.Config.DependsOn))
+	for i, dep := range proc.Config.DependsOn {
+		deps[i] = dep.String()
+	}
+	row("Depends On", strings.Join(deps, ", "))
+	if err := proc.Err(); err != nil {
+		row("Error", err.Error())
+	}
+	if hc := proc.Config.HealthCheck; hc != nil {
+		check := hc.Type + " " + hc.Target
+		switch hc.Type {
+		case config.HealthExec:
+			check = hc.Type + " " + hc.Command
+		case config.HealthLog:
+			check = fmt.Sprintf("log /%s/", hc.Pattern)
+		}
+		if proc.Flapping() {
+			check += " (flapping)"
+		}
+		row("Health", check)
+		if probes := proc.Probes(); len(probes) > 0 {
+			var marks strings.Builder
+			for _, probe := range probes {
+				if probe.Healthy {
+					marks.WriteString("✓")
+				} else {
+					marks.WriteString("✗")
+				}
+			}
+			last := probes[len(probes)-1]
+			row("Probes", fmt.Sprintf("%s (last took %s)", marks.String(), last.Duration.Round(time.Millisecond)))
+		}
+		if detail := proc.HealthDetail(); detail != "" {
+			row("Last Probe", detail)
+		}
+	}
+
+	if uptime := proc.Uptime(); uptime > 0 {
+		row("Started", proc.StartedAt().Format("15:04:05"))
+		row("Uptime", uptime.Round(time.Second).String())
+	}
+
+	history := proc.History()
+	if len(history) > 0 {
+		last := history[len(history)-1]
+		row("Last Exit", describeExit(last))
+
+		b.WriteString("\nRecent runs:\n")
+		for i := len(history) - 1; i >= 0; i-- {
+			run := history[i]
+			b.WriteString(fmt.Sprintf("  %s  %-8s %s\n",
+				run.StartedAt.Format("15:04:05"),
+				run.Uptime().Round(time.Second),
+				describeExit(run)))
+		}
+	}
+
+	// Show why a oneshot task failed without switching to its log
+	if proc.Config.Type == config.TaskOneshot && proc.State() == process.StateFailed {
+		if output := lastOutput(proc.Logs, 5); len(output) > 0 {
+			b.WriteString("\nLast output:\n")
+			for _, line := range output {
+				b.WriteString("  " + truncate(line, 70) + "\n")
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// formatDuration rounds d for display: tenths of a second below a minute,
+// whole seconds above.
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// lastOutput returns the text of the last n lines the task printed, skipping
+// DevDeck's own notes.
+func lastOutput(buffer *process.LogBuffer, n int) []string {
+	var out []string
+	lines := buffer.Tail(n * 4)
+	for i := len(lines) - 1; i >= 0 && len(out) < n; i-- {
+		if lines[i].Stream != process.StreamSystem {
+			out = append([]string{process.StripColors(lines[i].Text)}, out...)
+		}
+	}
+	return out
+}
+
+// describeExit summarizes how a run ended, e.g. "exit 1" or "SIGKILL (stopped)".
+func describeExit(run process.Run) string {
+	var desc string
+	if run.Signal != "" {
+		desc = run.Signal
+	} else {
+		desc = fmt.Sprintf("exit %d", run.ExitCode)
+	}
+	if run.Requested {
+		desc += " (stopped)"
+	}
+	return desc
+}